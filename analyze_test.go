@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+// writePackages writes a tree of packages, each exporting a few symbols
+func writePackages(tb testing.TB, count int) string {
+	tb.Helper()
+
+	dir := tb.TempDir()
+	for i := range count {
+		packageDir := filepath.Join(dir, fmt.Sprintf("pkg%d", i/10), fmt.Sprintf("sub%d", i%10))
+		if err := os.MkdirAll(packageDir, 0o755); err != nil {
+			tb.Fatal(err)
+		}
+		source := fmt.Sprintf("package sub%d\ntype Store interface{ Get(key string) string }\ntype MemStore struct{ Name string }\nfunc (m *MemStore) Get(key string) string { return m.Name }\nfunc New%d() *MemStore { return nil }\nconst Size = %d\n", i%10, i, i)
+		if err := os.WriteFile(filepath.Join(packageDir, "store.go"), []byte(source), 0o644); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestAnalyzePackageParallel(t *testing.T) {
+	t.Parallel()
+	assert := NewGomegaWithT(t)
+
+	dir := writePackages(t, 50)
+	sequential, err := analyzePackage(dir, analyzeOptions{recursive: true, parallel: 1})
+	assert.Expect(err).NotTo(HaveOccurred())
+	assert.Expect(sequential.Functions).To(HaveLen(50))
+
+	for range 5 {
+		parallel, err := analyzePackage(dir, analyzeOptions{recursive: true, parallel: 8})
+		assert.Expect(err).NotTo(HaveOccurred())
+		assert.Expect(parallel).To(Equal(sequential))
+	}
+}
+
+func TestAnalyzeFiles(t *testing.T) {
+	t.Parallel()
+	assert := NewGomegaWithT(t)
+
+	sources := map[string]string{
+		"store.go":  "package store\n\nimport t \"time\"\n\n// Store keeps values\ntype Store interface {\n\tGet(key string) (string, bool)\n}\n\ntype MemStore struct {\n\tbase\n\tTTL t.Duration\n}\n\nfunc (m *MemStore) Get(key string) (string, bool) { return \"\", false }\n",
+		"base.go":   "package store\n\ntype base struct{ Name string }\n\nconst (\n\tSmall = iota\n\tLarge\n)\n\n// Deprecated: use MemStore\nfunc New[K comparable](key K) *MemStore { return nil }\n",
+		"broken.go": "package store\nfunc {\n",
+		"notes.txt": "not Go",
+	}
+
+	dir := t.TempDir()
+	for filename, source := range sources {
+		assert.Expect(os.WriteFile(filepath.Join(dir, filename), []byte(source), 0o644)).To(Succeed())
+	}
+
+	fromDisk, err := analyzeDir(dir, analyzeOptions{})
+	assert.Expect(err).NotTo(HaveOccurred())
+
+	fromMemory, err := AnalyzeFiles(sources)
+	assert.Expect(err).NotTo(HaveOccurred())
+	assert.Expect(fromMemory).To(Equal(fromDisk))
+	assert.Expect(fromMemory.Functions).To(HaveKey("New"))
+	assert.Expect(fromMemory.PromotedFields).To(HaveKey("MemStore.Name"))
+}
+
+func TestAnalyzeInterfaceParamNames(t *testing.T) {
+	t.Parallel()
+	assert := NewGomegaWithT(t)
+
+	before, err := AnalyzeFiles(map[string]string{
+		"doer.go": "package doer\n\ntype Doer interface {\n\tDo(a int, hook func(event string)) (err error)\n}\n",
+	})
+	assert.Expect(err).NotTo(HaveOccurred())
+
+	after, err := AnalyzeFiles(map[string]string{
+		"doer.go": "package doer\n\ntype Doer interface {\n\tDo(b int, hook func(name string)) error\n}\n",
+	})
+	assert.Expect(err).NotTo(HaveOccurred())
+	assert.Expect(after.Types["Doer"]).To(Equal(before.Types["Doer"]))
+	assert.Expect(after.Types["Doer"]).NotTo(ContainSubstring("b int"))
+}
+
+func BenchmarkAnalyzePackage(b *testing.B) {
+	dir := writePackages(b, 200)
+
+	for _, parallel := range []int{1, 0} {
+		b.Run(fmt.Sprintf("parallel=%d", parallel), func(b *testing.B) {
+			for range b.N {
+				if _, err := analyzePackage(dir, analyzeOptions{recursive: true, parallel: parallel}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestAnalyzePositions(t *testing.T) {
+	t.Parallel()
+	assert := NewGomegaWithT(t)
+
+	exported, err := AnalyzeFiles(map[string]string{
+		"store.go": "package store\n\ntype Store struct{}\n\nfunc (s *Store) Get() string { return \"\" }\n\nconst (\n\tSmall = iota\n\tLarge\n)\n\nvar Default, other = Store{}, 1\n\nfunc helper() {}\n",
+	})
+	assert.Expect(err).NotTo(HaveOccurred())
+
+	for name, want := range map[string]string{
+		"Store":     "store.go:3:6",
+		"Store.Get": "store.go:5:17",
+		"Large":     "store.go:9:2",
+		"Default":   "store.go:12:5",
+	} {
+		assert.Expect(exported.Positions[name].String()).To(Equal(want), name)
+	}
+	assert.Expect(exported.Positions).NotTo(HaveKey("other"))
+	assert.Expect(exported.Positions).NotTo(HaveKey("helper"))
+
+	dir := writePackages(t, 1)
+	tree, err := analyzePackage(dir, analyzeOptions{recursive: true})
+	assert.Expect(err).NotTo(HaveOccurred())
+	assert.Expect(tree.Positions["pkg0/sub0.MemStore"].String()).To(Equal("pkg0/sub0/store.go:3:6"))
+}
+
+func TestAnalyzeIgnoreGenerated(t *testing.T) {
+	t.Parallel()
+	assert := NewGomegaWithT(t)
+
+	sources := map[string]string{
+		"api.go":       "package api\n\nfunc Run() {}\n",
+		"gen.go":       "// Code generated by stringer. DO NOT EDIT.\n\npackage api\n\nfunc Generated() {}\n",
+		"late.go":      "// Package api runs things.\n//\n// Code generated by hand. DO NOT EDIT.\npackage api\n\nfunc Late() {}\n",
+		"lowercase.go": "// Code generated by hand, do not edit\npackage api\n\nfunc Lowercase() {}\n",
+		"inline.go":    "// Code generated DO NOT EDIT.\npackage api\n\nfunc Inline() {}\n",
+		"body.go":      "package api\n\n// Code generated by hand. DO NOT EDIT.\nfunc Body() {}\n",
+	}
+
+	fset := token.NewFileSet()
+	files := make(map[string]*ast.File)
+	for filename, source := range sources {
+		file, err := parser.ParseFile(fset, filename, source, parser.ParseComments)
+		assert.Expect(err).NotTo(HaveOccurred())
+		files[filename] = file
+	}
+
+	exported := newExported()
+	assert.Expect(analyzePackageFiles(fset, files, analyzeOptions{ignoreGenerated: true}, &exported)).To(Succeed())
+	assert.Expect(sortedKeys(exported.Functions)).To(Equal([]string{"Body", "Inline", "Lowercase", "Run"}))
+
+	exported = newExported()
+	assert.Expect(analyzePackageFiles(fset, files, analyzeOptions{}, &exported)).To(Succeed())
+	assert.Expect(exported.Functions).To(HaveLen(6))
+}
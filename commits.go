@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// conventionalHeader matches the header of a conventional commit, such as
+// "feat(parser)!: support generics"
+var conventionalHeader = regexp.MustCompile(`^(\w+)(\([^)]*\))?(!)?: `)
+
+// commitsBump returns the bump implied by the conventional commit messages
+// made in dir since ref.
+func commitsBump(dir, ref string) (Bump, error) {
+	output, err := exec.Command("git", "-C", dir, "log", "--format=%B%x00", ref+"..HEAD").Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return Patch, fmt.Errorf("reading git log since %s: %w: %s", ref, err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return Patch, fmt.Errorf("reading git log since %s: %w", ref, err)
+	}
+
+	bump := Patch
+	for _, message := range strings.Split(string(output), "\x00") {
+		bump = max(bump, commitBump(strings.TrimSpace(message)))
+	}
+	return bump, nil
+}
+
+// commitBump classifies a single commit message: a breaking change is major,
+// a feature is minor, and anything else is a patch.
+func commitBump(message string) Bump {
+	header, _, _ := strings.Cut(message, "\n")
+	match := conventionalHeader.FindStringSubmatch(header)
+
+	if match != nil && match[3] == "!" {
+		return Major
+	}
+	for _, line := range strings.Split(message, "\n") {
+		if strings.HasPrefix(line, "BREAKING CHANGE:") || strings.HasPrefix(line, "BREAKING-CHANGE:") {
+			return Major
+		}
+	}
+	if match != nil && match[1] == "feat" {
+		return Minor
+	}
+	return Patch
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// typeDecls holds the underlying type expressions of the types declared in a
+// package, so that the comparability of a struct can follow its fields.
+type typeDecls map[string]ast.Expr
+
+func newTypeDecls(files map[string]*ast.File) typeDecls {
+	decls := make(typeDecls)
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			d, ok := decl.(*ast.GenDecl)
+			if !ok || d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				if typeSpec, ok := spec.(*ast.TypeSpec); ok {
+					decls[typeSpec.Name.Name] = typeSpec.Type
+				}
+			}
+		}
+	}
+	return decls
+}
+
+// comparableStructs returns the names of the exported struct types that can
+// be compared with == and used as map keys. Every field counts, exported or
+// not, and types of other packages are assumed to be comparable.
+func (t typeDecls) comparableStructs() map[string]bool {
+	structs := make(map[string]bool)
+	for name, expr := range t {
+		if _, ok := expr.(*ast.StructType); ok && ast.IsExported(name) && t.comparable(expr, map[string]bool{name: true}) {
+			structs[name] = true
+		}
+	}
+	return structs
+}
+
+func (t typeDecls) comparable(expr ast.Expr, visiting map[string]bool) bool {
+	switch e := expr.(type) {
+	case *ast.MapType, *ast.FuncType:
+		return false
+	case *ast.ArrayType:
+		return e.Len != nil && t.comparable(e.Elt, visiting)
+	case *ast.StructType:
+		for _, field := range e.Fields.List {
+			if !t.comparable(field.Type, visiting) {
+				return false
+			}
+		}
+		return true
+	case *ast.ParenExpr:
+		return t.comparable(e.X, visiting)
+	case *ast.IndexExpr:
+		return t.comparable(e.X, visiting)
+	case *ast.IndexListExpr:
+		return t.comparable(e.X, visiting)
+	case *ast.Ident:
+		decl, ok := t[e.Name]
+		if !ok || visiting[e.Name] {
+			return true
+		}
+		visiting[e.Name] = true
+		defer delete(visiting, e.Name)
+		return t.comparable(decl, visiting)
+	}
+	return true
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strconv"
+)
+
+// constSpec is a single named constant, with the type and value expression
+// repeated from the previous spec of its group when they are omitted. The doc
+// comments are those of the spec and then its group.
+type constSpec struct {
+	name  *ast.Ident
+	typ   ast.Expr
+	value ast.Expr
+	iota  int
+	doc   []*ast.CommentGroup
+}
+
+// constEvaluator resolves constant expressions built from literals, iota,
+// and other constants declared in the same package.
+type constEvaluator struct {
+	specs    map[string]constSpec
+	values   map[string]constant.Value
+	visiting map[string]bool
+}
+
+func newConstEvaluator(files map[string]*ast.File) *constEvaluator {
+	evaluator := &constEvaluator{
+		specs:    make(map[string]constSpec),
+		values:   make(map[string]constant.Value),
+		visiting: make(map[string]bool),
+	}
+
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			d, ok := decl.(*ast.GenDecl)
+			if !ok || d.Tok != token.CONST {
+				continue
+			}
+
+			var typ ast.Expr
+			var values []ast.Expr
+			for index, spec := range d.Specs {
+				valueSpec, ok := spec.(*ast.ValueSpec)
+				if !ok {
+					continue
+				}
+
+				// An omitted type and value list repeat the previous ones
+				if valueSpec.Type != nil || len(valueSpec.Values) > 0 {
+					typ, values = valueSpec.Type, valueSpec.Values
+				}
+
+				for i, name := range valueSpec.Names {
+					if name.Name == "_" {
+						continue
+					}
+
+					var value ast.Expr
+					if i < len(values) {
+						value = values[i]
+					}
+					evaluator.specs[name.Name] = constSpec{
+						name:  name,
+						typ:   typ,
+						value: value,
+						iota:  index,
+						doc:   []*ast.CommentGroup{valueSpec.Doc, d.Doc},
+					}
+				}
+			}
+		}
+	}
+
+	return evaluator
+}
+
+// resolve returns the value of the named constant, if it can be computed
+func (e *constEvaluator) resolve(name string) (constant.Value, bool) {
+	if value, ok := e.values[name]; ok {
+		return value, true
+	}
+
+	spec, ok := e.specs[name]
+	if !ok || spec.value == nil || e.visiting[name] {
+		return nil, false
+	}
+
+	e.visiting[name] = true
+	defer delete(e.visiting, name)
+
+	value, ok := e.eval(spec.value, spec.iota)
+	if !ok {
+		return nil, false
+	}
+
+	e.values[name] = value
+	return value, true
+}
+
+func (e *constEvaluator) eval(expr ast.Expr, iota int) (value constant.Value, ok bool) {
+	// go/constant panics on operands of mismatched kinds
+	defer func() {
+		if recover() != nil {
+			value, ok = nil, false
+		}
+	}()
+
+	switch x := expr.(type) {
+	case *ast.BasicLit:
+		value := constant.MakeFromLiteral(x.Value, x.Kind, 0)
+		return value, value.Kind() != constant.Unknown
+	case *ast.Ident:
+		switch x.Name {
+		case "iota":
+			return constant.MakeInt64(int64(iota)), true
+		case "true", "false":
+			return constant.MakeBool(x.Name == "true"), true
+		}
+		return e.resolve(x.Name)
+	case *ast.ParenExpr:
+		return e.eval(x.X, iota)
+	case *ast.UnaryExpr:
+		operand, ok := e.eval(x.X, iota)
+		if !ok {
+			return nil, false
+		}
+		return constant.UnaryOp(x.Op, operand, 0), true
+	case *ast.BinaryExpr:
+		left, ok := e.eval(x.X, iota)
+		if !ok {
+			return nil, false
+		}
+		right, ok := e.eval(x.Y, iota)
+		if !ok {
+			return nil, false
+		}
+		return evalBinary(x.Op, left, right)
+	case *ast.CallExpr:
+		// Conversions such as Color(1) keep the value of their operand
+		if len(x.Args) != 1 || !isConversion(x.Fun) {
+			return nil, false
+		}
+		return e.eval(x.Args[0], iota)
+	}
+
+	return nil, false
+}
+
+// isConversion reports whether a call in a constant expression converts its
+// operand to the type fun names, as in Color(1) or time.Duration(5), rather
+// than calling a builtin such as len or unsafe.Sizeof.
+func isConversion(fun ast.Expr) bool {
+	switch f := fun.(type) {
+	case *ast.Ident:
+		_, builtin := types.Universe.Lookup(f.Name).(*types.Builtin)
+		return !builtin
+	case *ast.SelectorExpr:
+		// The functions of unsafe are the only ones of another package that
+		// constant expressions can call
+		pkg, ok := f.X.(*ast.Ident)
+		return ok && pkg.Name != "unsafe"
+	case *ast.ParenExpr:
+		return isConversion(f.X)
+	}
+	return false
+}
+
+func evalBinary(op token.Token, left, right constant.Value) (constant.Value, bool) {
+	switch op {
+	case token.SHL, token.SHR:
+		shift, ok := constant.Uint64Val(constant.ToInt(right))
+		if !ok {
+			return nil, false
+		}
+		return constant.Shift(constant.ToInt(left), op, uint(shift)), true
+	case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+		return constant.MakeBool(constant.Compare(left, op, right)), true
+	case token.QUO:
+		// Integer division truncates, as in the Go spec
+		if left.Kind() == constant.Int && right.Kind() == constant.Int {
+			op = token.QUO_ASSIGN
+		}
+	}
+
+	value := constant.BinaryOp(left, op, right)
+	return value, value.Kind() != constant.Unknown
+}
+
+// formatConstValue renders the value of a constant, falling back to its
+// source expression, and position within the group for iota, when the value
+// cannot be computed.
+func formatConstValue(value constant.Value, expr ast.Expr, iota int) string {
+	if value != nil {
+		return value.ExactString()
+	}
+
+	if expr == nil {
+		return ""
+	}
+
+	formatted := types.ExprString(expr)
+	usesIota := false
+	ast.Inspect(expr, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok && ident.Name == "iota" {
+			usesIota = true
+		}
+		return !usesIota
+	})
+	if usesIota {
+		formatted += " (iota " + strconv.Itoa(iota) + ")"
+	}
+
+	return formatted
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"go/scanner"
+	"go/token"
+	"maps"
+)
+
+// cosmeticOnly reports whether the exported APIs have the same symbols, and
+// their signatures only differ in whitespace or comments. Signatures are
+// normalized when analyzed, so such differences hint at a gap in the
+// normalization, such as a state file written by another version.
+func cosmeticOnly(previous, current Exported) bool {
+	for _, signatures := range [][2]map[string]string{
+		{previous.Types, current.Types},
+		{previous.Functions, current.Functions},
+		{previous.Methods, current.Methods},
+		{previous.Constants, current.Constants},
+		{previous.Variables, current.Variables},
+		{previous.PromotedFields, current.PromotedFields},
+		{previous.TypeParams, current.TypeParams},
+	} {
+		if !sameTokens(signatures[0], signatures[1]) {
+			return false
+		}
+	}
+	return maps.Equal(previous.Deprecated, current.Deprecated) && maps.Equal(previous.Comparable, current.Comparable)
+}
+
+// sameTokens reports whether the maps have the same keys, and values made of
+// the same Go tokens
+func sameTokens(previous, current map[string]string) bool {
+	if len(previous) != len(current) {
+		return false
+	}
+	for name, signature := range previous {
+		other, ok := current[name]
+		if !ok {
+			return false
+		}
+		if signature != other && !equalTokens(signature, other) {
+			return false
+		}
+	}
+	return true
+}
+
+// equalTokens reports whether the sources scan to the same tokens
+func equalTokens(a, b string) bool {
+	aTokens, bTokens := tokens(a), tokens(b)
+	if len(aTokens) != len(bTokens) {
+		return false
+	}
+	for i := range aTokens {
+		if aTokens[i] != bTokens[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// tokens returns the tokens of the source, without comments, which the
+// scanner skips, or semicolons, which are interchangeable with line ends
+func tokens(src string) []string {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+
+	var s scanner.Scanner
+	s.Init(file, []byte(src), nil, 0)
+
+	var result []string
+	for {
+		_, tok, lit := s.Scan()
+		if tok == token.EOF {
+			return result
+		}
+		if tok == token.SEMICOLON {
+			continue
+		}
+		if lit == "" {
+			lit = tok.String()
+		}
+		result = append(result, lit)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestCosmeticOnly(t *testing.T) {
+	t.Parallel()
+
+	previous := newExported()
+	previous.Types["Handler"] = "interface {\n\tServe(string)   error\n}"
+	previous.Functions["New"] = "func( int ) Handler"
+
+	t.Run("whitespace and comments are cosmetic", func(t *testing.T) {
+		assert := NewGomegaWithT(t)
+
+		current := newExported()
+		current.Types["Handler"] = "interface{ Serve(string) error /* serves */ }"
+		current.Functions["New"] = "func(int) Handler"
+		assert.Expect(cosmeticOnly(previous, current)).To(BeTrue())
+	})
+
+	t.Run("a changed signature is not cosmetic", func(t *testing.T) {
+		assert := NewGomegaWithT(t)
+
+		current := newExported()
+		current.Types["Handler"] = "interface{ Serve(string) error }"
+		current.Functions["New"] = "func(int64) Handler"
+		assert.Expect(cosmeticOnly(previous, current)).To(BeFalse())
+	})
+
+	t.Run("a renamed symbol is not cosmetic", func(t *testing.T) {
+		assert := NewGomegaWithT(t)
+
+		current := newExported()
+		current.Types["Handler"] = "interface{ Serve(string) error }"
+		current.Functions["Make"] = "func(int) Handler"
+		assert.Expect(cosmeticOnly(previous, current)).To(BeFalse())
+	})
+}
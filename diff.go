@@ -0,0 +1,1238 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/types"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Bump is the version increment required by a change
+type Bump int
+
+const (
+	Patch Bump = iota
+	Minor
+	Major
+)
+
+func (b Bump) String() string {
+	switch b {
+	case Major:
+		return "major"
+	case Minor:
+		return "minor"
+	default:
+		return "patch"
+	}
+}
+
+// parseBump parses the name of a bump, as returned by Bump.String
+func parseBump(name string) (Bump, error) {
+	for _, bump := range []Bump{Patch, Minor, Major} {
+		if bump.String() == name {
+			return bump, nil
+		}
+	}
+	return Patch, fmt.Errorf("unknown bump %q, expected patch, minor, or major", name)
+}
+
+// ChangeKind describes how an exported symbol changed
+type ChangeKind string
+
+const (
+	Added   ChangeKind = "added"
+	Removed ChangeKind = "removed"
+	Changed ChangeKind = "changed"
+)
+
+// Change is a single difference between two exported APIs. A changed
+// signature also carries its previous and current text, unless the previous
+// one is only known by its hash.
+type Change struct {
+	Kind     ChangeKind
+	Symbol   string
+	Bump     Bump
+	Reason   string
+	Previous string
+	Current  string
+}
+
+// Diff returns the changes between the previous and current exported APIs,
+// sorted by kind, then symbol name, then reason, so that the same APIs always
+// give the same output.
+func Diff(previous, current Exported) []Change {
+	var changes []Change
+	changes = append(changes, diffSymbols("type", previous.Types, current.Types, compareType)...)
+	changes = append(changes, diffSymbols("function", previous.Functions, current.Functions, compareFunc)...)
+	changes = append(changes, diffSymbols("method", previous.Methods, current.Methods, compareMethod)...)
+	changes = append(changes, diffSymbols("constant", previous.Constants, current.Constants, compareConst)...)
+	changes = append(changes, diffSymbols("variable", previous.Variables, current.Variables, compareVar)...)
+	changes = append(changes, diffDeprecations(previous, current)...)
+	changes = append(changes, diffComparability(previous, current)...)
+	changes = append(changes, diffPromotedFields(previous, current)...)
+	changes = append(changes, diffImplementations(previous, current)...)
+	changes = append(changes, diffWellKnown(previous, current)...)
+	changes = append(changes, diffTypeParams(previous, current)...)
+	changes = detectRenamedTypes(previous, current, changes)
+	changes = groupRemovedPackages(previous, current, changes)
+
+	slices.SortStableFunc(changes, func(a, b Change) int {
+		return cmp.Or(
+			cmp.Compare(a.Kind, b.Kind),
+			cmp.Compare(a.Symbol, b.Symbol),
+			cmp.Compare(a.Reason, b.Reason),
+		)
+	})
+
+	return changes
+}
+
+// maxBump returns the largest bump required by the changes
+func maxBump(changes []Change) Bump {
+	bump := Patch
+	for _, change := range changes {
+		bump = max(bump, change.Bump)
+	}
+	return bump
+}
+
+// compareFn classifies the difference between two formatted signatures
+type compareFn func(previous, current string) (Bump, string)
+
+func diffSymbols(kind string, previous, current map[string]string, compare compareFn) []Change {
+	var changes []Change
+
+	for _, name := range sortedKeys(previous) {
+		previousSignature := previous[name]
+		currentSignature, exists := current[name]
+		switch {
+		case !exists:
+			changes = append(changes, Change{
+				Kind:   Removed,
+				Symbol: name,
+				Bump:   Major,
+				Reason: fmt.Sprintf("removed exported %s %s", kind, name),
+			})
+		case currentSignature != previousSignature:
+			if hashSignature(currentSignature) == hashSignature(previousSignature) {
+				continue
+			}
+
+			change := Change{Kind: Changed, Symbol: name, Bump: Major}
+
+			// Hashed signatures can only tell that something changed, so
+			// the change is assumed to break, even one that would be minor
+			reason := "signature changed"
+			if !strings.HasPrefix(previousSignature, hashPrefix) {
+				change.Bump, reason = compare(previousSignature, currentSignature)
+				change.Previous, change.Current = previousSignature, currentSignature
+			}
+			change.Reason = fmt.Sprintf("changed exported %s %s: %s", kind, name, reason)
+			changes = append(changes, change)
+		}
+	}
+
+	for _, name := range sortedKeys(current) {
+		if _, exists := previous[name]; !exists {
+			changes = append(changes, Change{
+				Kind:   Added,
+				Symbol: name,
+				Bump:   Minor,
+				Reason: fmt.Sprintf("added exported %s %s", kind, name),
+			})
+		}
+	}
+
+	return changes
+}
+
+// diffPromotedFields reports the fields that struct types no longer get from
+// their embedded types, or that now have another type. Adding a field is
+// already reported as a change to the struct type. A promoted field is often
+// lost by declaring a field of the same name, which hides it from every user
+// of the struct.
+func diffPromotedFields(previous, current Exported) []Change {
+	var changes []Change
+	for _, name := range sortedKeys(previous.PromotedFields) {
+		previousType := previous.PromotedFields[name]
+		currentType, exists := current.PromotedFields[name]
+		dot := strings.LastIndex(name, ".")
+		outer, field := name[:dot], name[dot+1:]
+		switch {
+		case !exists && !hasKey(current.Types, outer):
+			// Removing the type is already reported
+		case !exists && declaresField(current.Types[outer], field):
+			changes = append(changes, Change{
+				Kind:   Changed,
+				Symbol: name,
+				Bump:   Major,
+				Reason: fmt.Sprintf("changed exported type %s: added field %s shadows promoted field %s", outer, field, name),
+			})
+		case !exists:
+			changes = append(changes, Change{
+				Kind:   Removed,
+				Symbol: name,
+				Bump:   Major,
+				Reason: fmt.Sprintf("removed exported promoted field %s", name),
+			})
+		case currentType != previousType && hashSignature(currentType) != hashSignature(previousType):
+			reason := "type changed"
+			if !strings.HasPrefix(previousType, hashPrefix) {
+				reason = fmt.Sprintf("type changed from %s to %s", previousType, currentType)
+			}
+			changes = append(changes, Change{
+				Kind:   Changed,
+				Symbol: name,
+				Bump:   Major,
+				Reason: fmt.Sprintf("changed exported promoted field %s: %s", name, reason),
+			})
+		}
+	}
+	return changes
+}
+
+// declaresField reports whether the struct type has a named field
+func declaresField(signature, name string) bool {
+	expr, err := parser.ParseExpr(signature)
+	if err != nil {
+		return false
+	}
+	structType, ok := expr.(*ast.StructType)
+	if !ok {
+		return false
+	}
+	for _, field := range structFields(structType) {
+		if field.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// diffImplementations reports the types that no longer satisfy an interface
+// they used to, as every assignment of the type to the interface breaks, even
+// when each of the changes that caused it looks compatible on its own.
+func diffImplementations(previous, current Exported) []Change {
+	var changes []Change
+	for _, name := range sortedKeys(previous.Implements) {
+		if !hasKey(current.Types, name) {
+			continue
+		}
+		for _, interfaceName := range previous.Implements[name] {
+			if !hasKey(current.Types, interfaceName) || slices.Contains(current.Implements[name], interfaceName) {
+				continue
+			}
+			changes = append(changes, Change{
+				Kind:   Changed,
+				Symbol: name,
+				Bump:   Major,
+				Reason: fmt.Sprintf("changed exported type %s: no longer implements %s", name, interfaceName),
+			})
+		}
+	}
+	return changes
+}
+
+// diffWellKnown reports the types that start or stop implementing a
+// well-known interface, which changes how fmt, encoding/json, and errors
+// treat their values even when no signature breaks.
+func diffWellKnown(previous, current Exported) []Change {
+	var changes []Change
+	for _, name := range sortedKeys(current.Types) {
+		if !hasKey(previous.Types, name) {
+			continue
+		}
+		for _, interfaceName := range previous.WellKnown[name] {
+			if slices.Contains(current.WellKnown[name], interfaceName) {
+				continue
+			}
+			changes = append(changes, Change{
+				Kind:   Changed,
+				Symbol: name,
+				Bump:   Major,
+				Reason: fmt.Sprintf("changed exported type %s: no longer implements %s", name, interfaceName),
+			})
+		}
+		for _, interfaceName := range current.WellKnown[name] {
+			if slices.Contains(previous.WellKnown[name], interfaceName) {
+				continue
+			}
+			changes = append(changes, Change{
+				Kind:   Changed,
+				Symbol: name,
+				Bump:   Minor,
+				Reason: fmt.Sprintf("changed exported type %s: now implements %s", name, interfaceName),
+			})
+		}
+	}
+	return changes
+}
+
+// diffTypeParams reports the changes to the type parameters of the types
+// that still exist, including a type becoming generic, which breaks every
+// use of it without type arguments.
+func diffTypeParams(previous, current Exported) []Change {
+	var changes []Change
+	for _, name := range sortedKeys(current.Types) {
+		if !hasKey(previous.Types, name) {
+			continue
+		}
+		previousTypeParams, currentTypeParams := previous.TypeParams[name], current.TypeParams[name]
+		if previousTypeParams == currentTypeParams || hashSignature(previousTypeParams) == hashSignature(currentTypeParams) {
+			continue
+		}
+
+		bump, reasons := Major, []string{"type parameters changed"}
+		previousFields, previousOK := parseTypeParams(previousTypeParams)
+		currentFields, currentOK := parseTypeParams(currentTypeParams)
+		if previousOK && currentOK {
+			bump, reasons = compareTypeParams(previousFields, currentFields)
+		}
+		if len(reasons) == 0 {
+			continue
+		}
+		changes = append(changes, Change{
+			Kind:   Changed,
+			Symbol: name,
+			Bump:   bump,
+			Reason: fmt.Sprintf("changed exported type %s: %s", name, strings.Join(reasons, "; ")),
+		})
+	}
+	return changes
+}
+
+// detectRenamedTypes replaces the removal of a type and the addition of
+// another with the same definition by a single change for the likely rename.
+// It is still breaking without an alias, but tells users what to migrate to.
+// Methods that only moved to the renamed type are part of the rename.
+// Definitions shared by several removed or added types are left alone.
+func detectRenamedTypes(previous, current Exported, changes []Change) []Change {
+	removed := make(map[string][]string)
+	for _, name := range sortedKeys(previous.Types) {
+		if !hasKey(current.Types, name) {
+			removed[previous.Types[name]] = append(removed[previous.Types[name]], name)
+		}
+	}
+	added := make(map[string][]string)
+	for _, name := range sortedKeys(current.Types) {
+		if !hasKey(previous.Types, name) {
+			added[current.Types[name]] = append(added[current.Types[name]], name)
+		}
+	}
+
+	renames := make(map[string]string)
+	for signature, names := range removed {
+		if len(names) == 1 && len(added[signature]) == 1 {
+			renames[names[0]] = added[signature][0]
+		}
+	}
+	if len(renames) == 0 {
+		return changes
+	}
+
+	renamed := make(map[string]bool, len(renames))
+	for _, name := range renames {
+		renamed[name] = true
+	}
+
+	// Methods are moved when their signature only differs by the type name
+	movedMethods := make(map[string]string)
+	methodNames := make(map[string][]string)
+	for _, name := range sortedKeys(previous.Methods) {
+		dot := strings.LastIndex(name, ".")
+		typeName, method := name[:dot], name[dot+1:]
+		newTypeName, ok := renames[typeName]
+		if !ok || hasKey(current.Methods, name) {
+			continue
+		}
+		newName := newTypeName + "." + method
+		if currentSignature, exists := current.Methods[newName]; exists && !hasKey(previous.Methods, newName) &&
+			renameInSignature(previous.Methods[name], typeName, newTypeName) == currentSignature {
+			movedMethods[name] = newName
+			methodNames[typeName] = append(methodNames[typeName], method)
+		}
+	}
+	moved := make(map[string]bool, len(movedMethods))
+	for _, name := range movedMethods {
+		moved[name] = true
+	}
+
+	var detected []Change
+	for _, change := range changes {
+		switch {
+		case change.Kind == Removed && change.Reason == "removed exported type "+change.Symbol && renames[change.Symbol] != "":
+			reason := fmt.Sprintf("renamed exported type %s → %s", change.Symbol, renames[change.Symbol])
+			if methods := methodNames[change.Symbol]; len(methods) > 0 {
+				reason += fmt.Sprintf(", along with its methods %s", strings.Join(methods, ", "))
+			}
+			detected = append(detected, Change{
+				Kind:   Changed,
+				Symbol: change.Symbol,
+				Bump:   Major,
+				Reason: reason,
+			})
+		case change.Kind == Added && change.Reason == "added exported type "+change.Symbol && renamed[change.Symbol]:
+		case change.Kind == Removed && change.Reason == "removed exported method "+change.Symbol && movedMethods[change.Symbol] != "":
+		case change.Kind == Added && change.Reason == "added exported method "+change.Symbol && moved[change.Symbol]:
+		default:
+			detected = append(detected, change)
+		}
+	}
+	return detected
+}
+
+// renameInSignature returns the signature with every reference to the type,
+// such as in the receiver, renamed. Types may be qualified by their package
+// directory, which signatures do not use.
+func renameInSignature(signature, typeName, newTypeName string) string {
+	funcType, ok := parseFuncType(signature)
+	if !ok {
+		return signature
+	}
+
+	from, to := typeName[strings.LastIndex(typeName, ".")+1:], newTypeName[strings.LastIndex(newTypeName, ".")+1:]
+	ast.Inspect(funcType, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok && ident.Name == from {
+			ident.Name = to
+		}
+		return true
+	})
+
+	formatted, err := formatNode(funcType)
+	if err != nil {
+		return signature
+	}
+	return formatted
+}
+
+// groupRemovedPackages replaces the removal of every symbol of a package that
+// no longer exists with a single change for the package.
+func groupRemovedPackages(previous, current Exported, changes []Change) []Change {
+	for _, name := range sortedKeys(previous.Packages) {
+		if name == "" || current.Packages[name] {
+			continue
+		}
+
+		var grouped []Change
+		removed := false
+		for _, change := range changes {
+			if change.Kind == Removed && strings.HasPrefix(change.Symbol, name+".") {
+				removed = true
+				continue
+			}
+			grouped = append(grouped, change)
+		}
+		if !removed {
+			continue
+		}
+
+		changes = append(grouped, Change{
+			Kind:   Removed,
+			Symbol: name,
+			Bump:   Major,
+			Reason: "removed package " + name,
+		})
+	}
+	return changes
+}
+
+// diffDeprecations reports existing symbols that became deprecated, which
+// semantic versioning treats as a minor change.
+func diffDeprecations(previous, current Exported) []Change {
+	var changes []Change
+	for _, name := range sortedKeys(current.Deprecated) {
+		kind := symbolKind(previous, name)
+		if previous.Deprecated[name] || kind == "" || symbolKind(current, name) == "" {
+			continue
+		}
+
+		changes = append(changes, Change{
+			Kind:   Changed,
+			Symbol: name,
+			Bump:   Minor,
+			Reason: fmt.Sprintf("deprecated exported %s %s", kind, name),
+		})
+	}
+	return changes
+}
+
+// diffComparability reports struct types that can no longer be compared, such
+// as after adding a slice field, which breaks their use with == or as map keys.
+func diffComparability(previous, current Exported) []Change {
+	var changes []Change
+	for _, name := range sortedKeys(previous.Comparable) {
+		if current.Comparable[name] || !hasKey(current.Types, name) {
+			continue
+		}
+
+		changes = append(changes, Change{
+			Kind:   Changed,
+			Symbol: name,
+			Bump:   Major,
+			Reason: fmt.Sprintf("changed exported type %s: no longer comparable", name),
+		})
+	}
+	return changes
+}
+
+// symbolKind returns the kind of the named symbol, or "" if it does not exist
+func symbolKind(exported Exported, name string) string {
+	switch {
+	case hasKey(exported.Types, name):
+		return "type"
+	case hasKey(exported.Functions, name):
+		return "function"
+	case hasKey(exported.Methods, name):
+		return "method"
+	case hasKey(exported.Constants, name):
+		return "constant"
+	case hasKey(exported.Variables, name):
+		return "variable"
+	}
+	return ""
+}
+
+func hasKey(values map[string]string, key string) bool {
+	_, ok := values[key]
+	return ok
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func compareType(previous, current string) (Bump, string) {
+	// An alias is the type it names, while a defined type is a new one with
+	// its own methods, so turning one into the other breaks type switches
+	// and method sets, even for an alias of its underlying type.
+	previousTarget, previousAlias := strings.CutPrefix(previous, aliasPrefix)
+	currentTarget, currentAlias := strings.CutPrefix(current, aliasPrefix)
+	switch {
+	case previousAlias && currentAlias:
+		return Major, fmt.Sprintf("alias target changed: %s → %s", previousTarget, currentTarget)
+	case previousAlias:
+		return Major, fmt.Sprintf("alias of %s turned into a defined type", previousTarget)
+	case currentAlias:
+		return Major, fmt.Sprintf("defined type turned into an alias of %s", currentTarget)
+	}
+
+	previousExpr, previousErr := parser.ParseExpr(previous)
+	currentExpr, currentErr := parser.ParseExpr(current)
+	if previousErr != nil || currentErr != nil {
+		return Major, "type changed"
+	}
+
+	previousKind, currentKind := typeKind(previousExpr), typeKind(currentExpr)
+	if previousKind != currentKind {
+		// Every type implementing the interface outside the package breaks
+		if previousKind == "interface" {
+			return Major, fmt.Sprintf("interface → %s: all implementers broken", currentKind)
+		}
+		return Major, fmt.Sprintf("kind changed from %s to %s", previousKind, currentKind)
+	}
+
+	if previousKind == "struct" {
+		return compareStruct(previousExpr.(*ast.StructType), currentExpr.(*ast.StructType))
+	}
+
+	if previousKind == "interface" {
+		return compareInterface(previousExpr.(*ast.InterfaceType), currentExpr.(*ast.InterfaceType))
+	}
+
+	if previousKind == "named" {
+		previousName, currentName := types.ExprString(previousExpr), types.ExprString(currentExpr)
+		// Conversions and the range of values depend on the basic type
+		if isBasicType(previousName) && isBasicType(currentName) {
+			return Major, fmt.Sprintf("underlying type changed: %s → %s", previousName, currentName)
+		}
+		return Major, fmt.Sprintf("type changed from %s to %s", previousName, currentName)
+	}
+
+	previousMap, previousIsMap := previousExpr.(*ast.MapType)
+	currentMap, currentIsMap := currentExpr.(*ast.MapType)
+	if previousIsMap && currentIsMap {
+		return compareMap(previousMap, currentMap)
+	}
+
+	previousArray, previousIsArray := previousExpr.(*ast.ArrayType)
+	currentArray, currentIsArray := currentExpr.(*ast.ArrayType)
+	if previousIsArray && currentIsArray {
+		return compareArray(previousKind, previousArray, currentArray)
+	}
+
+	return Major, "type changed"
+}
+
+// indirectionChange describes two types that only differ by pointers, such
+// as net.Conn and *net.Conn, or []T and []*T.
+func indirectionChange(previous, current string) string {
+	if strings.ReplaceAll(previous, "*", "") != strings.ReplaceAll(current, "*", "") {
+		return ""
+	}
+
+	switch previousCount, currentCount := strings.Count(previous, "*"), strings.Count(current, "*"); {
+	case currentCount > previousCount:
+		return "added pointer indirection"
+	case currentCount < previousCount:
+		return "removed pointer indirection"
+	}
+	return ""
+}
+
+// anonymityChange describes a named type replaced by an anonymous struct, or
+// the reverse. Even with the same fields, the two are different types, so
+// values of one cannot be assigned to the other.
+func anonymityChange(previous, current string) string {
+	previousExpr, previousErr := parser.ParseExpr(previous)
+	currentExpr, currentErr := parser.ParseExpr(current)
+	if previousErr != nil || currentErr != nil {
+		return ""
+	}
+	// Pointers to both are compared by what they point to
+	for {
+		previousStar, previousOK := previousExpr.(*ast.StarExpr)
+		currentStar, currentOK := currentExpr.(*ast.StarExpr)
+		if !previousOK || !currentOK {
+			break
+		}
+		previousExpr, currentExpr = previousStar.X, currentStar.X
+	}
+
+	_, previousAnonymous := previousExpr.(*ast.StructType)
+	_, currentAnonymous := currentExpr.(*ast.StructType)
+	switch {
+	case isNamedType(previousExpr) && currentAnonymous:
+		return fmt.Sprintf("named type %s replaced by an anonymous struct", types.ExprString(previousExpr))
+	case previousAnonymous && isNamedType(currentExpr):
+		return fmt.Sprintf("anonymous struct replaced by named type %s", types.ExprString(currentExpr))
+	}
+	return ""
+}
+
+// isNamedType reports whether expr names a type, such as Settings,
+// config.Settings or Settings[string].
+func isNamedType(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.Ident, *ast.SelectorExpr:
+		return true
+	case *ast.IndexExpr:
+		return isNamedType(e.X)
+	case *ast.IndexListExpr:
+		return isNamedType(e.X)
+	}
+	return false
+}
+
+// compareArray reports the length and element type changes of an array or
+// slice type. Slices have no length, and kind tells which of the two it is.
+func compareArray(kind string, previous, current *ast.ArrayType) (Bump, string) {
+	var reasons []string
+	if kind == "array" {
+		previousLen, currentLen := types.ExprString(previous.Len), types.ExprString(current.Len)
+		if previousLen != currentLen {
+			reasons = append(reasons, fmt.Sprintf("array length changed from %s to %s", previousLen, currentLen))
+		}
+	}
+
+	previousElt, currentElt := types.ExprString(previous.Elt), types.ExprString(current.Elt)
+	if previousElt != currentElt {
+		reasons = append(reasons, fmt.Sprintf("%s element type changed from %s to %s", kind, previousElt, currentElt))
+	}
+
+	if len(reasons) == 0 {
+		return Major, "type changed"
+	}
+	return Major, strings.Join(reasons, "; ")
+}
+
+// compareMap reports the key and value type changes of a map type
+func compareMap(previous, current *ast.MapType) (Bump, string) {
+	var reasons []string
+	for _, part := range []struct {
+		name              string
+		previous, current ast.Expr
+	}{
+		{"key", previous.Key, current.Key},
+		{"value", previous.Value, current.Value},
+	} {
+		previousType, currentType := types.ExprString(part.previous), types.ExprString(part.current)
+		if previousType != currentType {
+			reasons = append(reasons, fmt.Sprintf("map %s type changed from %s to %s", part.name, previousType, currentType))
+		}
+	}
+
+	if len(reasons) == 0 {
+		return Major, "type changed"
+	}
+	return Major, strings.Join(reasons, "; ")
+}
+
+// structField is a single named field of a struct type
+type structField struct {
+	name string
+	typ  string
+	tag  string
+}
+
+// compareStruct compares the fields of two struct types. Adding a field is
+// source compatible for keyed literals, so it is minor, while removing a
+// field or changing its type is major.
+func compareStruct(previous, current *ast.StructType) (Bump, string) {
+	previousFields, currentFields := structFields(previous), structFields(current)
+
+	currentByName := make(map[string]structField, len(currentFields))
+	for _, field := range currentFields {
+		currentByName[field.name] = field
+	}
+	previousByName := make(map[string]structField, len(previousFields))
+	for _, field := range previousFields {
+		previousByName[field.name] = field
+	}
+
+	bump := Patch
+	var reasons []string
+	for _, previousField := range previousFields {
+		currentField, exists := currentByName[previousField.name]
+		switch {
+		case !exists:
+			bump = Major
+			reasons = append(reasons, fmt.Sprintf("removed field %s", previousField.name))
+		case currentField.typ != previousField.typ:
+			if interfaceBump, change, ok := compareInlineInterfaces(previousField.typ, currentField.typ); ok {
+				bump = max(bump, interfaceBump)
+				reasons = append(reasons, fmt.Sprintf("field %s interface changed: %s", previousField.name, change))
+				continue
+			}
+			bump = Major
+			if change := anonymityChange(previousField.typ, currentField.typ); change != "" {
+				reasons = append(reasons, fmt.Sprintf("field %s type changed: %s", previousField.name, change))
+			} else if change := indirectionChange(previousField.typ, currentField.typ); change != "" {
+				reasons = append(reasons, fmt.Sprintf("field %s type changed: %s", previousField.name, change))
+			} else {
+				reasons = append(reasons, fmt.Sprintf("field %s type changed from %s to %s", previousField.name, previousField.typ, currentField.typ))
+			}
+		case currentField.tag != previousField.tag:
+			// Tags are only stored when tracked, and do not affect the type,
+			// but the JSON encoding of a field is a wire contract
+			if change := jsonTagChange(previousField, currentField); change != "" {
+				bump = Major
+				reasons = append(reasons, change)
+				continue
+			}
+			bump = max(bump, Minor)
+			reasons = append(reasons, fmt.Sprintf("struct tags changed for field %s", previousField.name))
+		}
+	}
+
+	for _, currentField := range currentFields {
+		if _, exists := previousByName[currentField.name]; !exists {
+			bump = max(bump, Minor)
+			reasons = append(reasons, fmt.Sprintf("added field %s", currentField.name))
+		}
+	}
+
+	if len(reasons) == 0 {
+		return Minor, "fields reordered"
+	}
+
+	return bump, strings.Join(reasons, "; ")
+}
+
+// jsonTagChange describes how the json tag of a field changes its encoding,
+// or returns "" when the field keeps its JSON name.
+func jsonTagChange(previous, current structField) string {
+	previousName, previousOmitted := jsonName(previous)
+	currentName, currentOmitted := jsonName(current)
+	switch {
+	case !previousOmitted && currentOmitted:
+		return fmt.Sprintf("field %s is no longer encoded to JSON", previous.name)
+	case previousOmitted && !currentOmitted:
+		return fmt.Sprintf("field %s is now encoded to JSON as %q", previous.name, currentName)
+	case previousName != currentName:
+		return fmt.Sprintf("JSON name of field %s changed from %q to %q", previous.name, previousName, currentName)
+	}
+	return ""
+}
+
+// jsonName returns the key that encoding/json uses for the field, and whether
+// its json tag is "-", which leaves it out.
+func jsonName(field structField) (string, bool) {
+	tag, err := strconv.Unquote(field.tag)
+	if err != nil {
+		return field.name, false
+	}
+	value := reflect.StructTag(tag).Get("json")
+	if value == "-" {
+		return "", true
+	}
+	if name, _, _ := strings.Cut(value, ","); name != "" {
+		return name, false
+	}
+	return field.name, false
+}
+
+// compareInterface compares the methods and embedded elements of two
+// interfaces. Any change breaks either callers or implementations, and an
+// added unexported method breaks every implementation outside the package,
+// as none can declare it.
+func compareInterface(previous, current *ast.InterfaceType) (Bump, string) {
+	previousMethods, previousEmbeds := interfaceElements(previous)
+	currentMethods, currentEmbeds := interfaceElements(current)
+
+	var reasons []string
+	for _, name := range sortedKeys(previousMethods) {
+		currentSignature, exists := currentMethods[name]
+		switch {
+		case !exists:
+			reasons = append(reasons, fmt.Sprintf("removed method %s", name))
+		case currentSignature != previousMethods[name]:
+			reasons = append(reasons, fmt.Sprintf("method %s signature changed from %s to %s", name, previousMethods[name], currentSignature))
+		}
+	}
+	for _, name := range sortedKeys(currentMethods) {
+		if hasKey(previousMethods, name) {
+			continue
+		}
+		if ast.IsExported(name) {
+			reasons = append(reasons, fmt.Sprintf("added method %s", name))
+		} else {
+			reasons = append(reasons, fmt.Sprintf("added unexported method %s, so it can no longer be implemented outside the package", name))
+		}
+	}
+	if !slices.Equal(previousEmbeds, currentEmbeds) {
+		reasons = append(reasons, fmt.Sprintf("embedded types changed from [%s] to [%s]", strings.Join(previousEmbeds, ", "), strings.Join(currentEmbeds, ", ")))
+	}
+
+	if len(reasons) > 0 {
+		return Major, strings.Join(reasons, "; ")
+	}
+
+	// The method sets are the same, so the methods are in another order, or
+	// their parameters are named differently, which -strict-order stores
+	previousOrder, previousTypes := interfaceOrder(previous)
+	currentOrder, currentTypes := interfaceOrder(current)
+	bump := Patch
+	for _, name := range sortedKeys(previousTypes) {
+		namesBump, namesReasons := compareParamNames(previousTypes[name].Params, currentTypes[name].Params)
+		bump = max(bump, namesBump)
+		for _, reason := range namesReasons {
+			reasons = append(reasons, fmt.Sprintf("method %s %s", name, reason))
+		}
+	}
+	if !slices.Equal(previousOrder, currentOrder) {
+		reasons = append(reasons, "methods reordered")
+	}
+	if len(reasons) == 0 {
+		reasons = append(reasons, "parameter or result names changed")
+	}
+	return bump, strings.Join(reasons, "; ")
+}
+
+// compareInlineInterfaces compares the method sets of two field types when
+// both are anonymous interfaces, such as interface{ ServeHTTP() }.
+func compareInlineInterfaces(previous, current string) (Bump, string, bool) {
+	previousExpr, previousErr := parser.ParseExpr(previous)
+	currentExpr, currentErr := parser.ParseExpr(current)
+	if previousErr != nil || currentErr != nil {
+		return Major, "", false
+	}
+
+	previousInterface, previousOK := previousExpr.(*ast.InterfaceType)
+	currentInterface, currentOK := currentExpr.(*ast.InterfaceType)
+	if !previousOK || !currentOK {
+		return Major, "", false
+	}
+
+	bump, reason := compareInterface(previousInterface, currentInterface)
+	return bump, reason, true
+}
+
+// interfaceElements returns the signature of every method of the interface,
+// and its other elements, such as embedded interfaces and type terms, sorted
+func interfaceElements(interfaceType *ast.InterfaceType) (map[string]string, []string) {
+	methods := make(map[string]string)
+	var embeds []string
+	for _, field := range interfaceType.Methods.List {
+		funcType, ok := field.Type.(*ast.FuncType)
+		if !ok || len(field.Names) == 0 {
+			embeds = append(embeds, types.ExprString(field.Type))
+			continue
+		}
+		for _, name := range field.Names {
+			methods[name.Name] = types.ExprString(stripParamNames(funcType))
+		}
+	}
+	sort.Strings(embeds)
+	return methods, embeds
+}
+
+// interfaceOrder returns the methods and other elements of the interface in
+// the order they are declared, and the type of every method
+func interfaceOrder(interfaceType *ast.InterfaceType) ([]string, map[string]*ast.FuncType) {
+	var order []string
+	methods := make(map[string]*ast.FuncType)
+	for _, field := range interfaceType.Methods.List {
+		funcType, ok := field.Type.(*ast.FuncType)
+		if !ok || len(field.Names) == 0 {
+			order = append(order, types.ExprString(field.Type))
+			continue
+		}
+		for _, name := range field.Names {
+			order = append(order, name.Name)
+			methods[name.Name] = funcType
+		}
+	}
+	return order, methods
+}
+
+func structFields(structType *ast.StructType) []structField {
+	var fields []structField
+	for _, field := range structType.Fields.List {
+		tag := ""
+		if field.Tag != nil {
+			tag = field.Tag.Value
+		}
+		for _, name := range field.Names {
+			fields = append(fields, structField{name: name.Name, typ: types.ExprString(field.Type), tag: tag})
+		}
+	}
+	return fields
+}
+
+// isBasicType reports whether the name is a predeclared boolean, numeric, or
+// string type
+func isBasicType(name string) bool {
+	basic, ok := types.Universe.Lookup(name).(*types.TypeName)
+	if !ok {
+		return false
+	}
+	_, ok = basic.Type().(*types.Basic)
+	return ok
+}
+
+// typeKind names the kind of a type expression, where "named" covers any
+// reference to another type, such as string or io.Reader.
+func typeKind(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StructType:
+		return "struct"
+	case *ast.InterfaceType:
+		return "interface"
+	case *ast.FuncType:
+		return "func"
+	case *ast.MapType:
+		return "map"
+	case *ast.ArrayType:
+		if e.Len == nil {
+			return "slice"
+		}
+		return "array"
+	case *ast.ChanType:
+		return "chan"
+	case *ast.StarExpr:
+		return "pointer"
+	case *ast.ParenExpr:
+		return typeKind(e.X)
+	default:
+		return "named"
+	}
+}
+
+func compareFunc(previous, current string) (Bump, string) {
+	previousType, previousOK := parseFuncType(previous)
+	currentType, currentOK := parseFuncType(current)
+	if !previousOK || !currentOK {
+		return Major, "signature changed"
+	}
+
+	return compareFuncTypes(previousType, currentType)
+}
+
+// compareMethod classifies the change of a method stored as the type of its
+// method expression, whose first parameter is the receiver. Switching
+// between a value and a pointer receiver changes the method sets of the
+// receiver type, which can break interface satisfaction.
+func compareMethod(previous, current string) (Bump, string) {
+	previousType, previousOK := parseFuncType(previous)
+	currentType, currentOK := parseFuncType(current)
+	if !previousOK || !currentOK {
+		return Major, "signature changed"
+	}
+
+	previousReceiver, previousOK := splitReceiver(previousType)
+	currentReceiver, currentOK := splitReceiver(currentType)
+	if !previousOK || !currentOK {
+		return Major, "signature changed"
+	}
+
+	if previous, current := types.ExprString(previousReceiver), types.ExprString(currentReceiver); previous != current {
+		return Major, fmt.Sprintf("receiver changed from %s to %s", previous, current)
+	}
+
+	return compareFuncTypes(previousType, currentType)
+}
+
+// splitReceiver removes the receiver from the parameters of a method
+// expression type, returning it.
+func splitReceiver(funcType *ast.FuncType) (ast.Expr, bool) {
+	if funcType.Params == nil || len(funcType.Params.List) == 0 {
+		return nil, false
+	}
+
+	receiver := funcType.Params.List[0].Type
+	funcType.Params.List = funcType.Params.List[1:]
+	return receiver, true
+}
+
+func compareFuncTypes(previousType, currentType *ast.FuncType) (Bump, string) {
+	// The parameters of a function that became generic change with it
+	if change := genericityChange(previousType.TypeParams, currentType.TypeParams); change != "" {
+		return Major, change
+	}
+
+	typeParamsBump, typeParamsReasons := compareTypeParams(previousType.TypeParams, currentType.TypeParams)
+	paramsBump, paramsReasons := compareFieldLists("parameter", previousType.Params, currentType.Params, false)
+	resultsBump, resultsReasons := compareFieldLists("result", previousType.Results, currentType.Results, true)
+
+	if len(paramsReasons) == 0 {
+		paramsBump, paramsReasons = compareParamNames(previousType.Params, currentType.Params)
+	}
+
+	reasons := append(append(typeParamsReasons, paramsReasons...), resultsReasons...)
+	if len(reasons) == 0 {
+		return Major, "signature changed"
+	}
+
+	return max(typeParamsBump, paramsBump, resultsBump), strings.Join(reasons, "; ")
+}
+
+// errorResultChange tells when the only change to the results is a trailing
+// error, which callers now have to handle, or no longer get.
+func errorResultChange(previous, current []ast.Expr) string {
+	previousTypes, currentTypes := make([]string, 0, len(previous)), make([]string, 0, len(current))
+	for _, expr := range previous {
+		previousTypes = append(previousTypes, types.ExprString(expr))
+	}
+	for _, expr := range current {
+		currentTypes = append(currentTypes, types.ExprString(expr))
+	}
+
+	switch {
+	case len(currentTypes) == len(previousTypes)+1 && currentTypes[len(currentTypes)-1] == "error" && slices.Equal(previousTypes, currentTypes[:len(previousTypes)]):
+		return "now returns error"
+	case len(previousTypes) == len(currentTypes)+1 && previousTypes[len(previousTypes)-1] == "error" && slices.Equal(currentTypes, previousTypes[:len(currentTypes)]):
+		return "no longer returns error"
+	}
+	return ""
+}
+
+// compareParamNames compares the names of parameters of the same types, which
+// are only stored with -strict-order. Callers of parameters that swapped
+// names now pass their arguments in the wrong order, while other renames are
+// compatible.
+func compareParamNames(previous, current *ast.FieldList) (Bump, []string) {
+	previousNames, currentNames := paramNames(previous), paramNames(current)
+	if slices.Equal(previousNames, currentNames) {
+		return Patch, nil
+	}
+
+	previousSorted, currentSorted := slices.Sorted(slices.Values(previousNames)), slices.Sorted(slices.Values(currentNames))
+	if slices.Equal(previousSorted, currentSorted) && !slices.Contains(previousNames, "") {
+		return Major, []string{fmt.Sprintf("parameters reordered from (%s) to (%s)", strings.Join(previousNames, ", "), strings.Join(currentNames, ", "))}
+	}
+	return Patch, []string{fmt.Sprintf("parameters renamed from (%s) to (%s)", strings.Join(previousNames, ", "), strings.Join(currentNames, ", "))}
+}
+
+// paramNames returns the name of every parameter, "" when unnamed
+func paramNames(fields *ast.FieldList) []string {
+	if fields == nil {
+		return nil
+	}
+
+	var names []string
+	for _, field := range fields.List {
+		if len(field.Names) == 0 {
+			names = append(names, "")
+		}
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+	}
+	return names
+}
+
+// compareFieldLists compares the parameters or results of two signatures,
+// position by position when their count is unchanged.
+func compareFieldLists(label string, previous, current *ast.FieldList, result bool) (Bump, []string) {
+	previousTypes, currentTypes := fieldTypes(previous), fieldTypes(current)
+	if !result {
+		if reason := variadicChange(previousTypes, currentTypes); reason != "" {
+			return Major, []string{reason}
+		}
+	}
+	if len(previousTypes) != len(currentTypes) {
+		// Adding or removing a result breaks every assignment of the results
+		if result {
+			reasons := []string{fmt.Sprintf("%s count changed from %d to %d: %s to %s", label, len(previousTypes), len(currentTypes), formatTypeList(previousTypes), formatTypeList(currentTypes))}
+			if reason := errorResultChange(previousTypes, currentTypes); reason != "" {
+				reasons = append(reasons, reason)
+			}
+			return Major, reasons
+		}
+		return Major, []string{fmt.Sprintf("%ss changed from %s to %s", label, formatTypeList(previousTypes), formatTypeList(currentTypes))}
+	}
+
+	bump := Patch
+	var reasons []string
+	for i := range previousTypes {
+		if b, reason := compareFieldType(previousTypes[i], currentTypes[i], result); reason != "" {
+			bump = max(bump, b)
+			reasons = append(reasons, fmt.Sprintf("%s %d %s", label, i+1, reason))
+		}
+	}
+
+	return bump, reasons
+}
+
+// variadicChange describes a variadic parameter appended to, or dropped from,
+// otherwise identical parameters. Without overloading in Go, callers that
+// relied on the previous signature, such as by storing the function, break.
+func variadicChange(previous, current []ast.Expr) string {
+	shorter, longer, change := previous, current, "added"
+	if len(previous) > len(current) {
+		shorter, longer, change = current, previous, "removed"
+	}
+	if len(longer) != len(shorter)+1 {
+		return ""
+	}
+	if _, ok := longer[len(longer)-1].(*ast.Ellipsis); !ok {
+		return ""
+	}
+	for i := range shorter {
+		if types.ExprString(shorter[i]) != types.ExprString(longer[i]) {
+			return ""
+		}
+	}
+	return "signature changed: " + change + " variadic parameter"
+}
+
+// formatTypeList renders a list of types, such as "(int, error)"
+func formatTypeList(list []ast.Expr) string {
+	formatted := make([]string, 0, len(list))
+	for _, expr := range list {
+		formatted = append(formatted, types.ExprString(expr))
+	}
+	return "(" + strings.Join(formatted, ", ") + ")"
+}
+
+// compareVar compares the types of a variable. A type that cannot be told
+// from the declaration, such as that of a function result, is not compared.
+func compareVar(previous, current string) (Bump, string) {
+	if previous == "" || current == "" {
+		return Patch, "type could not be determined"
+	}
+	return Major, fmt.Sprintf("type changed from %s to %s", previous, current)
+}
+
+// compareConst classifies the change of a constant stored as "Type = value".
+// An untyped constant can be used wherever its value fits, so typing it
+// breaks some uses, while dropping the type of an unchanged value does not.
+func compareConst(previous, current string) (Bump, string) {
+	previousType, previousValue, _ := strings.Cut(previous, "= ")
+	currentType, currentValue, _ := strings.Cut(current, "= ")
+	previousType, currentType = strings.TrimSpace(previousType), strings.TrimSpace(currentType)
+
+	var reasons []string
+	bump := Major
+	switch {
+	case previousType == currentType:
+	case previousType == "":
+		reasons = append(reasons, "became typed as "+currentType)
+	case currentType == "":
+		bump = Minor
+		reasons = append(reasons, "became untyped, was "+previousType)
+	default:
+		reasons = append(reasons, fmt.Sprintf("type changed from %q to %q", previousType, currentType))
+	}
+	if previousValue != currentValue {
+		bump = Major
+		reasons = append(reasons, fmt.Sprintf("value changed from %s to %s", previousValue, currentValue))
+	}
+	if len(reasons) == 0 {
+		return Major, "signature changed"
+	}
+	return bump, strings.Join(reasons, "; ")
+}
+
+// compareFieldType classifies the change of a single parameter or result.
+// Results are covariant, so a change that grants callers more capability is
+// a widening; parameters are the opposite.
+func compareFieldType(previous, current ast.Expr, result bool) (Bump, string) {
+	previousString, currentString := types.ExprString(previous), types.ExprString(current)
+	if previousString == currentString {
+		return Patch, ""
+	}
+
+	previousChan, previousIsChan := previous.(*ast.ChanType)
+	currentChan, currentIsChan := current.(*ast.ChanType)
+	if previousIsChan && currentIsChan && types.ExprString(previousChan.Value) == types.ExprString(currentChan.Value) {
+		widened := currentChan.Dir == ast.SEND|ast.RECV
+		if !result {
+			widened = previousChan.Dir == ast.SEND|ast.RECV
+		}
+
+		reason := fmt.Sprintf("channel direction changed from %s to %s", previousString, currentString)
+		if widened {
+			return Minor, reason
+		}
+		return Major, reason
+	}
+
+	// Passing or returning a pointer instead of a value breaks every use
+	if star, ok := current.(*ast.StarExpr); ok && types.ExprString(star.X) == previousString {
+		return Major, "changed: value → pointer"
+	}
+	if star, ok := previous.(*ast.StarExpr); ok && types.ExprString(star.X) == currentString {
+		return Major, "changed: pointer → value"
+	}
+
+	return Major, fmt.Sprintf("type changed from %s to %s", previousString, currentString)
+}
+
+// parseFuncType parses a formatted function signature back into its AST
+func parseFuncType(signature string) (*ast.FuncType, bool) {
+	expr, err := parser.ParseExpr(signature)
+	if err != nil {
+		return parseGenericFuncType(signature)
+	}
+
+	funcType, ok := expr.(*ast.FuncType)
+	return funcType, ok
+}
+
+// fieldTypes returns the type of every parameter, one entry per name
+func fieldTypes(fields *ast.FieldList) []ast.Expr {
+	if fields == nil {
+		return nil
+	}
+
+	var list []ast.Expr
+	for _, field := range fields.List {
+		for range max(len(field.Names), 1) {
+			list = append(list, field.Type)
+		}
+	}
+	return list
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestDiffIsDeterministic(t *testing.T) {
+	t.Parallel()
+	assert := NewGomegaWithT(t)
+
+	previous, err := AnalyzeFiles(map[string]string{
+		"api.go": "package api\n\ntype Client struct{ Name string }\n\nfunc (c *Client) Close() error { return nil }\n\nfunc Parse(s string) int { return 0 }\n\nfunc Load() {}\n\nconst Limit = 1\n\nvar Default = &Client{}\n",
+	})
+	assert.Expect(err).NotTo(HaveOccurred())
+
+	current, err := AnalyzeFiles(map[string]string{
+		"api.go": "package api\n\ntype Client struct {\n\tName string\n\tTags []string\n}\n\n// Deprecated: use Load\nfunc Parse(s string) int64 { return 0 }\n\nfunc Load(path string) {}\n\nfunc Save() {}\n\nconst (\n\tLimit = 2\n\tBurst = 3\n)\n\nvar Default = Client{}\n",
+	})
+	assert.Expect(err).NotTo(HaveOccurred())
+
+	changes := Diff(previous, current)
+	assert.Expect(len(changes)).To(BeNumerically(">", 5))
+	for range 20 {
+		assert.Expect(Diff(previous, current)).To(Equal(changes))
+	}
+
+	for i := 1; i < len(changes); i++ {
+		previousChange, change := changes[i-1], changes[i]
+		assert.Expect(previousChange.Kind <= change.Kind).To(BeTrue())
+		if previousChange.Kind == change.Kind {
+			assert.Expect(previousChange.Symbol <= change.Symbol).To(BeTrue())
+		}
+	}
+}
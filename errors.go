@@ -0,0 +1,34 @@
+package main
+
+import "errors"
+
+// The sentinels tell failures apart with errors.Is, such as an empty package,
+// which is a valid API, from one that failed to parse. semtype is a command,
+// so they are only used by run and the tests, not by importers.
+var (
+	// ErrStateCorrupt is returned when the state file cannot be decoded
+	ErrStateCorrupt = errors.New("state file is corrupt")
+	// ErrNoSources is returned when there are no Go files to analyze
+	ErrNoSources = errors.New("no Go files")
+	// ErrParse is returned when a Go file fails to parse
+	ErrParse = errors.New("failed to parse Go file")
+)
+
+// sentinelError marks an error with one of the sentinels above, for
+// errors.Is, while keeping the message of the underlying error.
+type sentinelError struct {
+	sentinel error
+	err      error
+}
+
+func (e *sentinelError) Error() string {
+	return e.err.Error()
+}
+
+func (e *sentinelError) Unwrap() []error {
+	return []error{e.sentinel, e.err}
+}
+
+func markError(sentinel, err error) error {
+	return &sentinelError{sentinel: sentinel, err: err}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("corrupt state file", func(t *testing.T) {
+		assert := NewGomegaWithT(t)
+
+		stateFile := filepath.Join(t.TempDir(), "semtype.dat")
+		assert.Expect(os.WriteFile(stateFile, []byte("not gob"), 0o644)).To(Succeed())
+
+		_, err := loadState(stateFile, "0.0.0")
+		assert.Expect(errors.Is(err, ErrStateCorrupt)).To(BeTrue())
+		assert.Expect(err.Error()).To(HavePrefix("decoding state file: "))
+	})
+
+	t.Run("empty directory", func(t *testing.T) {
+		assert := NewGomegaWithT(t)
+
+		_, err := analyzePackage(t.TempDir(), analyzeOptions{})
+		assert.Expect(errors.Is(err, ErrNoSources)).To(BeTrue())
+
+		_, err = analyzePackage(t.TempDir(), analyzeOptions{recursive: true})
+		assert.Expect(errors.Is(err, ErrNoSources)).To(BeTrue())
+	})
+
+	t.Run("unparseable file", func(t *testing.T) {
+		assert := NewGomegaWithT(t)
+
+		dir := t.TempDir()
+		assert.Expect(os.WriteFile(filepath.Join(dir, "broken.go"), []byte("package main\nfunc Broken( {\n"), 0o644)).To(Succeed())
+
+		_, err := analyzePackage(dir, analyzeOptions{strictParse: true})
+		assert.Expect(errors.Is(err, ErrParse)).To(BeTrue())
+		assert.Expect(errors.Is(err, ErrNoSources)).To(BeFalse())
+	})
+}
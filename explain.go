@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxExplained is the number of changes listed by an explanation before the
+// rest are only counted
+const maxExplained = 3
+
+// explain summarizes the version decision in one line, listing the changes
+// that required the largest bump first.
+func explain(previous, next Version, changes []Change) string {
+	bump := versionBump(previous, next)
+	summary := fmt.Sprintf("Bumping %s → %s (%s)", previous, next, bump)
+	if len(changes) == 0 {
+		return summary + ": no API changes"
+	}
+
+	var reasons []string
+	for _, level := range []Bump{Major, Minor, Patch} {
+		for _, change := range changes {
+			if change.Bump == level && len(reasons) < maxExplained {
+				reasons = append(reasons, change.Reason)
+			}
+		}
+	}
+	if rest := len(changes) - len(reasons); rest > 0 {
+		reasons[len(reasons)-1] += fmt.Sprintf(", and %d more", rest)
+	}
+
+	return summary + ": " + strings.Join(reasons, "; ")
+}
+
+// verboseDiff shows the previous and current signature of every changed
+// symbol, one line per "-" or "+", such as to paste into release notes.
+func verboseDiff(changes []Change) string {
+	var builder strings.Builder
+	for _, change := range changes {
+		if change.Kind != Changed || change.Previous == "" {
+			continue
+		}
+		fmt.Fprintf(&builder, "@@ %s @@\n", change.Symbol)
+		for _, line := range strings.Split(change.Previous, "\n") {
+			fmt.Fprintf(&builder, "-%s\n", line)
+		}
+		for _, line := range strings.Split(change.Current, "\n") {
+			fmt.Fprintf(&builder, "+%s\n", line)
+		}
+	}
+	return builder.String()
+}
+
+// changeSummary counts the changes of each kind, printed by -summary-json
+type changeSummary struct {
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+	Changed int    `json:"changed"`
+	Bump    string `json:"bump"`
+	Version string `json:"version"`
+}
+
+func summarize(version string, bump Bump, changes []Change) changeSummary {
+	summary := changeSummary{Bump: bump.String(), Version: version}
+	for _, change := range changes {
+		switch change.Kind {
+		case Added:
+			summary.Added++
+		case Removed:
+			summary.Removed++
+		case Changed:
+			summary.Changed++
+		}
+	}
+	return summary
+}
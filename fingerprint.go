@@ -0,0 +1,77 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// inputFingerprint hashes everything the recorded API depends on: the Go
+// files of every analyzed directory, go.mod and go.sum, and the options that
+// change what is recorded. Runs with the same fingerprint record the same API.
+func inputFingerprint(config *config, options analyzeOptions) (string, error) {
+	dirs := []string{config.dir}
+	if len(config.dirs) > 0 {
+		dirs = config.dirs
+	} else if len(config.roots) > 0 {
+		dirs = nil
+		for _, root := range config.roots {
+			dirs = append(dirs, filepath.Join(config.dir, root))
+		}
+	}
+
+	var files []string
+	for _, dir := range dirs {
+		packages := []packageDir{{path: dir}}
+		if options.recursive {
+			var err error
+			packages, err = packageDirs(dir, options)
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return "", err
+			}
+		}
+		for _, pkg := range packages {
+			entries, err := os.ReadDir(pkg.path)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			} else if err != nil {
+				return "", fmt.Errorf("reading directory: %w", err)
+			}
+			for _, entry := range entries {
+				if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".go") {
+					files = append(files, filepath.Join(pkg.path, entry.Name()))
+				}
+			}
+		}
+	}
+	files = append(files, filepath.Join(config.dir, "go.mod"), filepath.Join(config.dir, "go.sum"))
+	sort.Strings(files)
+
+	// The number of workers never changes the result
+	options.parallel = 0
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%+v %q %t %t\n", options, config.only, config.fullSignatures, config.positions)
+	for _, file := range files {
+		contents, err := os.ReadFile(file)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return "", fmt.Errorf("reading %s: %w", file, err)
+		}
+
+		rel, err := filepath.Rel(config.dir, file)
+		if err != nil {
+			return "", err
+		}
+		sum := sha256.Sum256(contents)
+		fmt.Fprintf(hash, "%s %x\n", filepath.ToSlash(rel), sum)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// expandConstraints returns a copy of the type parameters with constraints
+// that are interfaces of the package replaced by their methods and terms, so
+// that the diff can tell a relaxed constraint from a tightened one.
+func expandConstraints(typeParams *ast.FieldList, interfaces interfaceSet) *ast.FieldList {
+	if typeParams == nil {
+		return nil
+	}
+
+	list := make([]*ast.Field, 0, len(typeParams.List))
+	for _, field := range typeParams.List {
+		constraint := field.Type
+		switch c := constraint.(type) {
+		case *ast.Ident:
+			if interfaceType, ok := interfaces[c.Name]; ok {
+				constraint = interfaces.expand(interfaceType)
+			}
+		case *ast.InterfaceType:
+			constraint = interfaces.expand(c)
+		}
+		list = append(list, &ast.Field{Names: field.Names, Type: constraint})
+	}
+
+	return &ast.FieldList{Opening: typeParams.Opening, List: list, Closing: typeParams.Closing}
+}
+
+// parseGenericFuncType parses a formatted generic function signature, which
+// is not a valid expression, as the signature of a function declaration.
+func parseGenericFuncType(signature string) (*ast.FuncType, bool) {
+	if !strings.HasPrefix(signature, "func[") {
+		return nil, false
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), "", "package p\nfunc _"+strings.TrimPrefix(signature, "func"), 0)
+	if err != nil || len(file.Decls) != 1 {
+		return nil, false
+	}
+
+	decl, ok := file.Decls[0].(*ast.FuncDecl)
+	if !ok {
+		return nil, false
+	}
+	return decl.Type, true
+}
+
+// parseTypeParams parses type parameters formatted by formatTypeParams, and
+// returns nil for "", the type parameters of a type that is not generic.
+func parseTypeParams(typeParams string) (*ast.FieldList, bool) {
+	if typeParams == "" {
+		return nil, true
+	}
+
+	funcType, ok := parseGenericFuncType("func" + typeParams + "()")
+	if !ok {
+		return nil, false
+	}
+	return funcType.TypeParams, true
+}
+
+// compareTypeParams compares the type parameters of two signatures position
+// by position. Callers can keep using a relaxed constraint, which accepts
+// every type argument the previous one did, so that is only a minor change.
+func compareTypeParams(previous, current *ast.FieldList) (Bump, []string) {
+	if change := genericityChange(previous, current); change != "" {
+		return Major, []string{change}
+	}
+
+	previousNames, previousConstraints := typeParamList(previous)
+	currentNames, currentConstraints := typeParamList(current)
+	if len(previousNames) != len(currentNames) {
+		return Major, []string{fmt.Sprintf("number of type parameters changed from %d to %d: %s to %s", len(previousNames), len(currentNames), formatTypeParams(previousNames, previousConstraints), formatTypeParams(currentNames, currentConstraints))}
+	}
+
+	bump := Patch
+	var reasons []string
+	for i := range previousNames {
+		previousString, currentString := types.ExprString(previousConstraints[i]), types.ExprString(currentConstraints[i])
+		if previousString == currentString {
+			continue
+		}
+
+		previousSet, currentSet := newTypeSet(previousConstraints[i]), newTypeSet(currentConstraints[i])
+		narrowed, widened := !currentSet.contains(previousSet), !previousSet.contains(currentSet)
+		switch {
+		case !narrowed && !widened:
+			reasons = append(reasons, fmt.Sprintf("type parameter %s constraint rewritten from %s to %s", currentNames[i], previousString, currentString))
+		case !narrowed:
+			bump = max(bump, Minor)
+			reasons = append(reasons, fmt.Sprintf("type parameter %s constraint relaxed from %s to %s", currentNames[i], previousString, currentString))
+		case !widened:
+			bump = Major
+			reasons = append(reasons, fmt.Sprintf("type parameter %s constraint tightened from %s to %s", currentNames[i], previousString, currentString))
+		default:
+			bump = Major
+			reasons = append(reasons, fmt.Sprintf("type parameter %s constraint changed from %s to %s", currentNames[i], previousString, currentString))
+		}
+	}
+
+	return bump, reasons
+}
+
+// genericityChange describes a signature that became generic or stopped
+// being generic, or returns "" when neither happened. Every use of it without
+// type arguments, or with them, no longer compiles.
+func genericityChange(previous, current *ast.FieldList) string {
+	previousNames, previousConstraints := typeParamList(previous)
+	currentNames, currentConstraints := typeParamList(current)
+	switch {
+	case len(previousNames) == 0 && len(currentNames) > 0:
+		return "became generic with type parameters " + formatTypeParams(currentNames, currentConstraints)
+	case len(previousNames) > 0 && len(currentNames) == 0:
+		return "is no longer generic, dropping type parameters " + formatTypeParams(previousNames, previousConstraints)
+	}
+	return ""
+}
+
+// typeParamList returns the name and constraint of every type parameter
+func typeParamList(fields *ast.FieldList) ([]string, []ast.Expr) {
+	if fields == nil {
+		return nil, nil
+	}
+
+	var names []string
+	var constraints []ast.Expr
+	for _, field := range fields.List {
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+			constraints = append(constraints, field.Type)
+		}
+	}
+	return names, constraints
+}
+
+// formatTypeParams renders type parameters, such as "[K comparable, V any]"
+func formatTypeParams(names []string, constraints []ast.Expr) string {
+	formatted := make([]string, 0, len(names))
+	for i, name := range names {
+		formatted = append(formatted, name+" "+types.ExprString(constraints[i]))
+	}
+	return "[" + strings.Join(formatted, ", ") + "]"
+}
+
+// typeSet approximates the set of types that satisfy a constraint: those
+// that have all the methods, are comparable when required, and, unless any
+// type is allowed, match one of the terms. A constraint that cannot be
+// understood, such as one from another package, is opaque and only
+// contains itself.
+type typeSet struct {
+	opaque     string
+	methods    map[string]bool
+	comparable bool
+	anyType    bool
+	terms      map[string]bool
+}
+
+func newTypeSet(constraint ast.Expr) typeSet {
+	set := typeSet{methods: make(map[string]bool), anyType: true}
+	if !set.add(constraint) {
+		return typeSet{opaque: types.ExprString(constraint)}
+	}
+	return set
+}
+
+// add intersects the set with a constraint element, reporting whether the
+// element could be understood.
+func (s *typeSet) add(element ast.Expr) bool {
+	switch e := element.(type) {
+	case *ast.ParenExpr:
+		return s.add(e.X)
+	case *ast.Ident:
+		switch e.Name {
+		case "any":
+			return true
+		case "comparable":
+			s.comparable = true
+			return true
+		}
+	case *ast.InterfaceType:
+		for _, field := range e.Methods.List {
+			if len(field.Names) > 0 {
+				for _, name := range field.Names {
+					s.methods[name.Name+types.ExprString(field.Type)[len("func"):]] = true
+				}
+				continue
+			}
+			if !s.add(field.Type) {
+				return false
+			}
+		}
+		return true
+	case *ast.SelectorExpr:
+		// Constraints of other packages are not known
+		return false
+	}
+
+	terms, ok := unionTerms(element)
+	if !ok {
+		return false
+	}
+	if s.anyType {
+		s.anyType, s.terms = false, terms
+		return true
+	}
+	narrowed := make(map[string]bool)
+	for term := range s.terms {
+		switch underlying, approximate := strings.CutPrefix(term, "~"); {
+		case termsContain(terms, term):
+			narrowed[term] = true
+		case approximate && terms[underlying]:
+			// The intersection of ~int and int is int
+			narrowed[underlying] = true
+		}
+	}
+	s.terms = narrowed
+	return true
+}
+
+// unionTerms returns the terms of a union such as "~int | string"
+func unionTerms(expr ast.Expr) (map[string]bool, bool) {
+	terms := make(map[string]bool)
+	var walk func(ast.Expr) bool
+	walk = func(expr ast.Expr) bool {
+		switch e := expr.(type) {
+		case *ast.BinaryExpr:
+			return e.Op == token.OR && walk(e.X) && walk(e.Y)
+		case *ast.InterfaceType, *ast.SelectorExpr:
+			return false
+		}
+		terms[types.ExprString(expr)] = true
+		return true
+	}
+	return terms, walk(expr)
+}
+
+// termsContain reports whether a term, such as "int", is matched by the
+// terms, either exactly or through an approximation such as "~int".
+func termsContain(terms map[string]bool, term string) bool {
+	return terms[term] || terms["~"+strings.TrimPrefix(term, "~")]
+}
+
+// contains reports whether every type in other is also in the set
+func (s typeSet) contains(other typeSet) bool {
+	if s.opaque != "" || other.opaque != "" {
+		return s.opaque == other.opaque
+	}
+
+	for method := range s.methods {
+		if !other.methods[method] {
+			return false
+		}
+	}
+	if s.comparable && !other.comparable && !other.onlyComparableTerms() {
+		return false
+	}
+	if s.anyType {
+		return true
+	}
+	if other.anyType {
+		return false
+	}
+	for _, term := range sortedKeys(other.terms) {
+		if !termsContain(s.terms, term) {
+			return false
+		}
+	}
+	return true
+}
+
+// onlyComparableTerms reports whether the set is restricted to basic types,
+// which are all comparable.
+func (s typeSet) onlyComparableTerms() bool {
+	if s.anyType {
+		return false
+	}
+	for term := range s.terms {
+		if types.Universe.Lookup(strings.TrimPrefix(term, "~")) == nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"go/parser"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestTypeSetIntersection(t *testing.T) {
+	t.Parallel()
+	assert := NewGomegaWithT(t)
+
+	for constraint, want := range map[string][]string{
+		"interface{ ~int; int }":                    {"int"},
+		"interface{ int; ~int }":                    {"int"},
+		"interface{ ~int | string; int | ~string }": {"int", "string"},
+		"interface{ ~int; ~int | float64 }":         {"~int"},
+		"interface{ int; string }":                  {},
+	} {
+		expr, err := parser.ParseExpr(constraint)
+		assert.Expect(err).NotTo(HaveOccurred())
+
+		set := newTypeSet(expr)
+		assert.Expect(set.anyType).To(BeFalse(), constraint)
+		assert.Expect(sortedKeys(set.terms)).To(ConsistOf(want), constraint)
+	}
+}
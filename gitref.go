@@ -0,0 +1,148 @@
+package main
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// gitOutput runs git in dir and returns its trimmed output
+func gitOutput(dir string, args ...string) (string, error) {
+	output, err := exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("running git %s: %w: %s", args[0], err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("running git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// checkoutRef extracts dir, as it was at the git revision, into a temporary
+// directory, without touching the working tree. The caller removes the
+// directory once done.
+func checkoutRef(dir, ref string) (string, error) {
+	// git archive limits the archive to the working directory, which would be
+	// looked up again within the tree of the subdirectory, so it runs at the
+	// root
+	root, err := gitOutput(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("finding %s in the repository: %w", dir, err)
+	}
+	prefix, err := gitOutput(dir, "rev-parse", "--show-prefix")
+	if err != nil {
+		return "", fmt.Errorf("finding %s in the repository: %w", dir, err)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "semtype-ref-")
+	if err != nil {
+		return "", fmt.Errorf("creating directory for %s: %w", ref, err)
+	}
+
+	command := exec.Command("git", "-C", root, "archive", "--format=tar", ref+":"+prefix)
+	var stderr strings.Builder
+	command.Stderr = &stderr
+	stdout, err := command.StdoutPipe()
+	if err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return "", fmt.Errorf("archiving %s: %w", ref, err)
+	}
+	if err := command.Start(); err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return "", fmt.Errorf("archiving %s: %w", ref, err)
+	}
+
+	extractErr := extractTar(stdout, tmpDir)
+	// Drain the archive so that git does not block on a failed extraction
+	_, _ = io.Copy(io.Discard, stdout)
+	if err := command.Wait(); err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return "", fmt.Errorf("archiving %s: %w: %s", ref, err, strings.TrimSpace(stderr.String()))
+	}
+	if extractErr != nil {
+		_ = os.RemoveAll(tmpDir)
+		return "", fmt.Errorf("extracting %s: %w", ref, extractErr)
+	}
+
+	return tmpDir, nil
+}
+
+// extractTar writes the directories and regular files of the archive to dir
+func extractTar(r io.Reader, dir string) error {
+	reader := tar.NewReader(r)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !filepath.IsLocal(header.Name) {
+			return fmt.Errorf("invalid path %q in archive", header.Name)
+		}
+
+		target := filepath.Join(dir, header.Name)
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(file, reader)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// loadRef returns the state of the package in dir at the git revision, at the
+// version of the closest tag reachable from it, or at initial without one.
+func loadRef(dir, ref string, roots []string, initial string, options analyzeOptions) (State, error) {
+	exported, err := analyzeRef(dir, ref, roots, options)
+	if errors.Is(err, ErrNoSources) {
+		slog.Warn("no Go files to analyze", "ref", ref, "error", err)
+	} else if err != nil {
+		return State{}, err
+	}
+
+	version := parseVersion(initial).String()
+	if tag, err := gitOutput(dir, "describe", "--tags", "--abbrev=0", ref); err != nil {
+		slog.Warn("no tag found, using initial version", "ref", ref, "version", version, "error", err)
+	} else {
+		version = parseVersion(tag).String()
+	}
+	return State{Version: version, Exported: exported}, nil
+}
+
+// analyzeRef analyzes the package in dir as it was at the git revision
+func analyzeRef(dir, ref string, roots []string, options analyzeOptions) (Exported, error) {
+	refDir, err := checkoutRef(dir, ref)
+	if err != nil {
+		return newExported(), err
+	}
+	defer func() { _ = os.RemoveAll(refDir) }()
+
+	exported, err := analyzeRoots(refDir, roots, options)
+	if err != nil && !errors.Is(err, ErrNoSources) {
+		return exported, fmt.Errorf("analyzing %s: %w", ref, err)
+	}
+	return exported, err
+}
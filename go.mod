@@ -2,11 +2,13 @@ module github.com/jtarchie/semtype
 
 go 1.23.4
 
-require github.com/onsi/gomega v1.38.2
+require (
+	github.com/onsi/gomega v1.38.2
+	go.yaml.in/yaml/v3 v3.0.4
+)
 
 require (
 	github.com/google/go-cmp v0.7.0 // indirect
-	go.yaml.in/yaml/v3 v3.0.4 // indirect
 	golang.org/x/net v0.43.0 // indirect
 	golang.org/x/text v0.28.0 // indirect
 )
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// hookInput is the JSON document written to the stdin of a hook
+type hookInput struct {
+	PreviousVersion string       `json:"previous_version"`
+	Version         string       `json:"version"`
+	Bump            string       `json:"bump"`
+	Changes         []hookChange `json:"changes"`
+}
+
+type hookChange struct {
+	Kind   ChangeKind `json:"kind"`
+	Symbol string     `json:"symbol"`
+	Bump   string     `json:"bump"`
+	Reason string     `json:"reason"`
+}
+
+// runHook passes the computed version and the changes to the hook, and
+// returns the version it prints, which may differ, such as by adding build
+// metadata.
+func runHook(hook, previousVersion string, version Version, changes []Change) (Version, error) {
+	input := hookInput{
+		PreviousVersion: previousVersion,
+		Version:         version.String(),
+		Bump:            versionBump(parseVersion(previousVersion), version).String(),
+		Changes:         []hookChange{},
+	}
+	for _, change := range changes {
+		input.Changes = append(input.Changes, hookChange{
+			Kind:   change.Kind,
+			Symbol: change.Symbol,
+			Bump:   change.Bump.String(),
+			Reason: change.Reason,
+		})
+	}
+
+	stdin, err := json.Marshal(input)
+	if err != nil {
+		return Version{}, fmt.Errorf("encoding hook input: %w", err)
+	}
+
+	command := exec.Command(hook)
+	command.Stdin = bytes.NewReader(stdin)
+	output, err := command.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return Version{}, fmt.Errorf("running %s: %w: %s", hook, err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return Version{}, fmt.Errorf("running %s: %w", hook, err)
+	}
+
+	hooked, err := readVersion(strings.TrimSpace(string(output)))
+	if err != nil {
+		return Version{}, fmt.Errorf("reading version from %s: %w", hook, err)
+	}
+	return hooked, nil
+}
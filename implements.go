@@ -0,0 +1,131 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// implementations returns, for every exported type with methods, the exported
+// interfaces of the same package that its pointer method set satisfies.
+// Interfaces that embed types, such as interfaces of other packages or type
+// sets, cannot be checked from the signatures alone, so they are left out.
+func implementations(exported Exported) map[string][]string {
+	interfaces := make(map[string]map[string]string)
+	for name, signature := range exported.Types {
+		expr, err := parser.ParseExpr(signature)
+		if err != nil {
+			continue
+		}
+		interfaceType, ok := expr.(*ast.InterfaceType)
+		if !ok {
+			continue
+		}
+		if methods, ok := interfaceMethods(interfaceType); ok && len(methods) > 0 {
+			interfaces[name] = methods
+		}
+	}
+
+	implements := make(map[string][]string)
+	for typeName, methodSet := range methodSets(exported, true) {
+		for interfaceName, methods := range interfaces {
+			if typeName != interfaceName && satisfies(methodSet, methods) {
+				implements[typeName] = append(implements[typeName], interfaceName)
+			}
+		}
+		sort.Strings(implements[typeName])
+	}
+	return implements
+}
+
+// wellKnownInterfaces are the interfaces of the standard library whose
+// implementations change how other packages, such as fmt and encoding/json,
+// treat a type.
+var wellKnownInterfaces = map[string]string{
+	"encoding.TextMarshaler":   "interface{ MarshalText() ([]byte, error) }",
+	"encoding.TextUnmarshaler": "interface{ UnmarshalText([]byte) error }",
+	"error":                    "interface{ Error() string }",
+	"fmt.Stringer":             "interface{ String() string }",
+	"json.Marshaler":           "interface{ MarshalJSON() ([]byte, error) }",
+	"json.Unmarshaler":         "interface{ UnmarshalJSON([]byte) error }",
+}
+
+// wellKnownImplementations returns, for every exported type with methods, the
+// well-known interfaces that its pointer method set satisfies.
+func wellKnownImplementations(exported Exported) map[string][]string {
+	interfaces := make(map[string]map[string]string, len(wellKnownInterfaces))
+	for name, source := range wellKnownInterfaces {
+		expr, err := parser.ParseExpr(source)
+		if err != nil {
+			panic(err)
+		}
+		interfaces[name], _ = interfaceMethods(expr.(*ast.InterfaceType))
+	}
+
+	implements := make(map[string][]string)
+	for typeName, methodSet := range methodSets(exported, true) {
+		for interfaceName, methods := range interfaces {
+			if satisfies(methodSet, methods) {
+				implements[typeName] = append(implements[typeName], interfaceName)
+			}
+		}
+		sort.Strings(implements[typeName])
+	}
+	return implements
+}
+
+// methodSets returns the signatures of the methods of every type, without
+// their receivers, keyed by type and then method name. The pointer method set
+// of a type has every method, while its value method set leaves out those
+// with a pointer receiver.
+func methodSets(exported Exported, pointer bool) map[string]map[string]string {
+	methodSets := make(map[string]map[string]string)
+	for name, signature := range exported.Methods {
+		dot := strings.LastIndex(name, ".")
+		typeName, method := name[:dot], name[dot+1:]
+		funcType, ok := parseFuncType(signature)
+		if !ok {
+			continue
+		}
+		receiver, ok := splitReceiver(funcType)
+		if !ok {
+			continue
+		}
+		if _, pointerReceiver := receiver.(*ast.StarExpr); pointerReceiver && !pointer {
+			continue
+		}
+		if methodSets[typeName] == nil {
+			methodSets[typeName] = make(map[string]string)
+		}
+		methodSets[typeName][method] = types.ExprString(stripParamNames(funcType))
+	}
+	return methodSets
+}
+
+// interfaceMethods returns the signature of every method of the interface,
+// and false when the interface has other elements.
+func interfaceMethods(interfaceType *ast.InterfaceType) (map[string]string, bool) {
+	methods := make(map[string]string)
+	for _, field := range interfaceType.Methods.List {
+		funcType, ok := field.Type.(*ast.FuncType)
+		if !ok || len(field.Names) == 0 {
+			return nil, false
+		}
+		for _, name := range field.Names {
+			methods[name.Name] = types.ExprString(stripParamNames(funcType))
+		}
+	}
+	return methods, true
+}
+
+// satisfies reports whether the method set has every method of the interface
+func satisfies(methodSet, methods map[string]string) bool {
+	for name, signature := range methods {
+		if methodSet[name] != signature {
+			return false
+		}
+	}
+	return true
+}
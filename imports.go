@@ -0,0 +1,141 @@
+package main
+
+import (
+	"go/ast"
+	"go/build"
+	"go/token"
+	"log/slog"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// versionSuffix matches the major version element of an import path, such as
+// "/v2" or the ".v3" of gopkg.in paths
+var versionSuffix = regexp.MustCompile(`[/.]v[0-9]+$`)
+
+// qualifyImports rewrites references through an aliased import to use the
+// name the imported package declares, found from dir like the go command
+// does, so that "t.Duration" with `import t "time"` is stored the same as
+// "time.Duration". An alias is kept when the package cannot be found, as a
+// guessed name could be that of another package, and when the name is already
+// used by another import of the file, as a name that depends on the other
+// imports would change whenever one is added.
+func qualifyImports(file *ast.File, dir string) {
+	candidates := make(map[*ast.ImportSpec]string)
+	for _, spec := range file.Imports {
+		if spec.Name == nil || spec.Name.Name == "_" || spec.Name.Name == "." {
+			continue
+		}
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		if name := packageName(importPath, dir); name != "" && name != spec.Name.Name {
+			candidates[spec] = name
+		}
+	}
+	if len(candidates) == 0 {
+		return
+	}
+
+	used := make(map[string]bool)
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		switch {
+		case spec.Name != nil:
+			used[spec.Name.Name] = true
+		case packageName(importPath, dir) != "":
+			used[packageName(importPath, dir)] = true
+		default:
+			// A package that cannot be found may still take the name
+			used[importName(importPath)] = true
+		}
+	}
+
+	renames := make(map[string]string)
+	for _, spec := range file.Imports {
+		name, ok := candidates[spec]
+		if !ok || used[name] {
+			continue
+		}
+		renames[spec.Name.Name] = name
+		used[name] = true
+	}
+
+	if len(renames) == 0 {
+		return
+	}
+
+	ast.Inspect(file, func(node ast.Node) bool {
+		selector, ok := node.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+
+		if ident, ok := selector.X.(*ast.Ident); ok {
+			if name, ok := renames[ident.Name]; ok {
+				selector.X = &ast.Ident{NamePos: ident.NamePos, Name: name}
+			}
+		}
+		return true
+	})
+}
+
+// packageNames caches the declared package names by import path and the
+// directory they are imported from, as packages are analyzed in parallel.
+var packageNames = struct {
+	sync.Mutex
+	names map[[2]string]string
+}{names: make(map[[2]string]string)}
+
+// packageName returns the name declared by the package with the import path,
+// as imported from dir, or "" when it cannot be found, such as a dependency
+// that is not downloaded.
+func packageName(importPath, dir string) string {
+	packageNames.Lock()
+	defer packageNames.Unlock()
+
+	key := [2]string{importPath, dir}
+	if name, ok := packageNames.names[key]; ok {
+		return name
+	}
+
+	// The go command is run in dir, so that the imports are found in the
+	// module being analyzed rather than that of the working directory
+	context := build.Default
+	context.Dir = dir
+
+	name := ""
+	pkg, err := context.Import(importPath, dir, 0)
+	if err == nil && token.IsIdentifier(pkg.Name) {
+		name = pkg.Name
+	} else {
+		slog.Debug("keeping the alias of an import that cannot be found", "import", importPath, "error", err)
+	}
+	packageNames.names[key] = name
+	return name
+}
+
+// importName guesses the package name of an import from its path, following
+// the common conventions for major versions and "go-" prefixes, for a package
+// that cannot be found. It returns "" when no valid name can be derived.
+func importName(importPath string) string {
+	trimmed := versionSuffix.ReplaceAllString(importPath, "")
+	if trimmed == "" {
+		trimmed = importPath
+	}
+
+	name := path.Base(trimmed)
+	name = strings.TrimPrefix(name, "go-")
+	name = strings.TrimSuffix(name, "-go")
+	if !token.IsIdentifier(name) {
+		return ""
+	}
+	return name
+}
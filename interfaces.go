@@ -0,0 +1,65 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// interfaceSet holds the interface types declared in a package, so that the
+// interfaces they embed can be replaced by the methods they contribute.
+type interfaceSet map[string]*ast.InterfaceType
+
+func newInterfaceSet(files map[string]*ast.File) interfaceSet {
+	interfaces := make(interfaceSet)
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			d, ok := decl.(*ast.GenDecl)
+			if !ok || d.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range d.Specs {
+				typeSpec, ok := spec.(*ast.TypeSpec)
+				if !ok || typeSpec.TypeParams != nil {
+					continue
+				}
+				if interfaceType, ok := typeSpec.Type.(*ast.InterfaceType); ok {
+					interfaces[typeSpec.Name.Name] = interfaceType
+				}
+			}
+		}
+	}
+	return interfaces
+}
+
+// expand returns a copy of the interface with every embedded interface of
+// the package replaced by its methods, so that a method added to an embedded
+// interface changes the embedding one too. Other embedded types, such as
+// those of other packages and type constraints, are kept as they are.
+func (s interfaceSet) expand(interfaceType *ast.InterfaceType) *ast.InterfaceType {
+	return &ast.InterfaceType{
+		Interface: interfaceType.Interface,
+		Methods: &ast.FieldList{
+			Opening: interfaceType.Methods.Opening,
+			List:    s.methods(interfaceType, map[*ast.InterfaceType]bool{}),
+			Closing: interfaceType.Methods.Closing,
+		},
+	}
+}
+
+func (s interfaceSet) methods(interfaceType *ast.InterfaceType, visiting map[*ast.InterfaceType]bool) []*ast.Field {
+	visiting[interfaceType] = true
+	defer delete(visiting, interfaceType)
+
+	var list []*ast.Field
+	for _, field := range interfaceType.Methods.List {
+		if ident, ok := field.Type.(*ast.Ident); ok && len(field.Names) == 0 {
+			if embedded, ok := s[ident.Name]; ok && !visiting[embedded] {
+				list = append(list, s.methods(embedded, visiting)...)
+				continue
+			}
+		}
+		list = append(list, field)
+	}
+	return list
+}
@@ -1,222 +1,1917 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
-	"encoding/gob"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"go/ast"
 	"go/format"
 	"go/parser"
 	"go/token"
+	"io"
+	"io/fs"
 	"log/slog"
 	"os"
+	"path"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.yaml.in/yaml/v3"
 )
 
-// Exported holds the exported types and functions from a Go package
+// Exported holds the exported types, functions, methods, and constants from a
+// Go package. Methods are keyed by their receiver type and name, e.g.
+// "Type.Method". Deprecated holds the names of symbols marked as deprecated.
+// Packages holds the names of the analyzed packages, which prefix the names
+// of their symbols, with "" for the package at the analyzed directory.
+// Comparable holds the names of struct types that support ==.
+// PromotedFields holds the types of the fields that struct types get from
+// the types they embed, keyed like methods.
+// Implements holds the interfaces of the same package that each type
+// satisfies, and WellKnown the well-known interfaces of the standard library,
+// such as fmt.Stringer, when they are tracked.
+// TypeParams holds the type parameters of generic types, such as
+// "[K comparable, V any]". Variables holds the types of package-level
+// variables, with "" when it cannot be told from the declaration.
+// Positions holds where each declared symbol is, with file names relative to
+// the analyzed directory, for the state file and logs, and is never compared.
 type Exported struct {
-	Types     map[string]string
-	Functions map[string]string
+	Types          map[string]string
+	Functions      map[string]string
+	Methods        map[string]string
+	Constants      map[string]string
+	Deprecated     map[string]bool
+	Packages       map[string]bool
+	Comparable     map[string]bool
+	PromotedFields map[string]string
+	Implements     map[string][]string
+	WellKnown      map[string][]string
+	TypeParams     map[string]string
+	Variables      map[string]string
+	Positions      map[string]token.Position
+}
+
+func newExported() Exported {
+	return Exported{
+		Types:      make(map[string]string),
+		Functions:  make(map[string]string),
+		Methods:    make(map[string]string),
+		Constants:  make(map[string]string),
+		Deprecated: make(map[string]bool),
+		Packages:   make(map[string]bool),
+		Comparable: make(map[string]bool),
+
+		PromotedFields: make(map[string]string),
+		Implements:     make(map[string][]string),
+		WellKnown:      make(map[string][]string),
+		TypeParams:     make(map[string]string),
+		Variables:      make(map[string]string),
+		Positions:      make(map[string]token.Position),
+	}
+}
+
+// empty reports whether no symbols are recorded
+func (e Exported) empty() bool {
+	return len(e.Types) == 0 && len(e.Functions) == 0 && len(e.Methods) == 0 && len(e.Constants) == 0 && len(e.Variables) == 0
 }
 
-// State represents the current state of the semantic versioning analysis
+// State represents the current state of the semantic versioning analysis.
+// With -per-package, Packages holds the state of every package instead,
+// keyed by import path. Fingerprint is the hash of the inputs the version
+// was computed from.
 type State struct {
+	Version     string
+	Exported    Exported
+	History     []Snapshot
+	Packages    map[string]State
+	Fingerprint string
+}
+
+// Snapshot is the exported API as it was at a previous version
+type Snapshot struct {
 	Version  string
 	Exported Exported
 }
 
-// Version represents a semantic version
+// defaultMaxHistory is the number of previous snapshots kept in the state
+// file when -max-history is not given
+const defaultMaxHistory = 10
+
+// snapshot returns the exported API recorded for the version, if any
+func (s State) snapshot(version string) (Exported, bool) {
+	if s.Version == version {
+		return s.Exported, true
+	}
+	for _, snapshot := range s.History {
+		if snapshot.Version == version {
+			return snapshot.Exported, true
+		}
+	}
+	return Exported{}, false
+}
+
+// nextHistory returns the history to store alongside a new version, which
+// includes the current version and drops the oldest snapshots beyond
+// maxHistory.
+func (s State) nextHistory(maxHistory int) []Snapshot {
+	history := s.History
+	// The initial state was never a released version
+	if !s.Exported.empty() {
+		history = append(history, Snapshot{Version: s.Version, Exported: s.Exported})
+	}
+	if len(history) > maxHistory {
+		history = history[len(history)-maxHistory:]
+	}
+	return history
+}
+
+// recordedBump returns the bump that led to the recorded version
+func (s State) recordedBump() Bump {
+	previous := Version{}
+	if len(s.History) > 0 {
+		previous = parseVersion(s.History[len(s.History)-1].Version)
+	}
+	return versionBump(previous, parseVersion(s.Version))
+}
+
+// Version represents a semantic version, with optional build metadata
 type Version struct {
 	Major, Minor, Patch int
+	Build               string
 }
 
+// logLevel is the minimum level logged to stderr
+var logLevel = new(slog.LevelVar)
+
 func main() {
-	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, nil)))
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel})))
+
+	if err := run(); err != nil {
+		slog.Error("execution failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run() (err error) {
+	config, err := parseFlags()
+	if err != nil {
+		return fmt.Errorf("parsing flags: %w", err)
+	}
+
+	if config.printConfig {
+		return printConfig(flag.CommandLine)
+	}
+
+	stopProfiling, err := startProfiling(config.cpuProfile, config.memProfile)
+	if err != nil {
+		return fmt.Errorf("profiling: %w", err)
+	}
+	defer func() {
+		if stopErr := stopProfiling(); stopErr != nil {
+			err = errors.Join(err, fmt.Errorf("profiling: %w", stopErr))
+		}
+	}()
+
+	// Errors are still logged, as they explain the non-zero exit
+	if config.quiet {
+		logLevel.Set(slog.LevelError)
+	}
+	if config.trace {
+		logLevel.Set(slog.LevelDebug)
+	}
+
+	options := analyzeOptions{
+		strictParse:     config.strictParse,
+		trackTags:       config.trackTags,
+		recursive:       config.recursive,
+		includeInternal: config.includeInternal,
+		parallel:        config.parallel,
+		strictOrder:     config.strictOrder,
+		trackWellKnown:  config.trackWellKnown,
+		ignoreGenerated: config.ignoreGenerated,
+	}
+
+	if config.listSymbols {
+		return listSymbols(config, options)
+	}
+
+	if config.perPackage {
+		return releasePerPackage(config, options)
+	}
+
+	if config.watch {
+		// Watching only shows the prospective version
+		config.dryRun = true
+		return watch(config.dir, func() error {
+			return release(config, options)
+		})
+	}
+
+	return release(config, options)
+}
+
+// release compares the current API with the previous one and prints the
+// resulting version, saving it in the state file.
+func release(config *config, options analyzeOptions) error {
+	var err error
+	var previousState State
+	if config.base != "" {
+		baseExported, err := analyzeRoots(config.base, config.roots, options)
+		if errors.Is(err, ErrNoSources) {
+			slog.Warn("no Go files to analyze", "error", err)
+		} else if err != nil {
+			return fmt.Errorf("analyzing base: %w", err)
+		}
+		previousState = State{Version: parseVersion(config.initial).String(), Exported: baseExported}
+	} else if config.baseRef != "" {
+		previousState, err = loadRef(config.dir, config.baseRef, config.roots, config.initial, options)
+		if err != nil {
+			return fmt.Errorf("loading -base-ref: %w", err)
+		}
+	} else if config.proxyBaseline {
+		previousState, err = loadProxyBaseline(config.dir, config.roots, options)
+		if err != nil {
+			return fmt.Errorf("loading baseline from module proxy: %w", err)
+		}
+	} else if config.baselineState != "" {
+		previousState, err = loadState(config.baselineState, config.initial)
+		if err != nil {
+			return fmt.Errorf("loading baseline state: %w", err)
+		}
+	} else {
+		previousState, err = loadState(config.stateFile, config.initial)
+		if err != nil {
+			return fmt.Errorf("loading state: %w", err)
+		}
+	}
+
+	// Unchanged inputs record the same API, so the recorded version stands
+	var inputs string
+	if config.incremental && config.base == "" && config.baseRef == "" && !config.proxyBaseline && config.headRef == "" && config.file == "" && config.since == "" {
+		inputs, err = inputFingerprint(config, options)
+		if err != nil {
+			return fmt.Errorf("fingerprinting inputs: %w", err)
+		}
+		if !config.force && inputs == previousState.Fingerprint {
+			slog.Info("skipping analysis, as the inputs are unchanged", "version", previousState.Version)
+			return printVersion(config, parseVersion(previousState.Version), previousState.recordedBump(), nil)
+		}
+	}
+
+	var currentExported Exported
+	if config.file != "" {
+		currentExported, err = analyzeFile(config.file, options)
+	} else if config.headRef != "" {
+		currentExported, err = analyzeRef(config.dir, config.headRef, config.roots, options)
+	} else if len(config.dirs) > 0 {
+		currentExported, err = analyzeDirList(config.dirs, options)
+	} else {
+		currentExported, err = analyzeRoots(config.dir, config.roots, options)
+	}
+	// An empty package is a valid, if unusual, API
+	if errors.Is(err, ErrNoSources) {
+		slog.Warn("no Go files to analyze", "error", err)
+	} else if err != nil {
+		return fmt.Errorf("analyzing package: %w", err)
+	}
+
+	if len(config.only) > 0 {
+		previousState.Exported = previousState.Exported.only(config.only)
+		currentExported = currentExported.only(config.only)
+	}
+	// Positions change with every edit above a symbol, so they are only
+	// recorded when asked for
+	if !config.positions {
+		currentExported.Positions = make(map[string]token.Position)
+	}
+
+	if config.since != "" {
+		since := parseVersion(config.since).String()
+		snapshot, ok := previousState.snapshot(since)
+		if !ok {
+			return fmt.Errorf("no snapshot recorded for version %s", since)
+		}
+
+		if len(config.only) > 0 {
+			snapshot = snapshot.only(config.only)
+		}
+		for _, change := range Diff(snapshot, currentExported) {
+			fmt.Printf("%s: %s\n", change.Bump, change.Reason)
+		}
+		return nil
+	}
+
+	changes := Diff(previousState.Exported, currentExported)
+	if config.smartWiden {
+		changes = widenParams(currentExported, changes)
+	}
+	for _, change := range changes {
+		if !config.positions {
+			slog.Info("detected change", "kind", change.Kind, "symbol", change.Symbol, "bump", change.Bump.String(), "reason", change.Reason)
+			continue
+		}
+
+		position, ok := currentExported.Positions[change.Symbol]
+		if change.Kind == Removed || !ok {
+			position = previousState.Exported.Positions[change.Symbol]
+		}
+		slog.Info("detected change", "kind", change.Kind, "symbol", change.Symbol, "bump", change.Bump.String(), "reason", change.Reason, "position", position.String())
+	}
+
+	if config.forcePatch && len(changes) > 0 && cosmeticOnly(previousState.Exported, currentExported) {
+		slog.Warn("forcing a patch bump, as the exported symbols and their tokens are unchanged", "changes", len(changes))
+		for i := range changes {
+			changes[i].Bump = Patch
+		}
+	}
+
+	minBump := config.minBump
+	if config.commitsSince != "" {
+		bump, err := commitsBump(config.dir, config.commitsSince)
+		if err != nil {
+			return fmt.Errorf("reading commits: %w", err)
+		}
+		minBump = max(minBump, bump)
+	}
+
+	newVersion := calculateVersion(previousState, changes, minBump)
+	if config.hook != "" {
+		newVersion, err = runHook(config.hook, previousState.Version, newVersion, changes)
+		if err != nil {
+			return fmt.Errorf("running hook: %w", err)
+		}
+	}
+
+	if config.checkModulePath {
+		modulePath, _, err := findModule(config.dir)
+		if err != nil {
+			return fmt.Errorf("finding module path: %w", err)
+		}
+		if err := checkModulePath(modulePath, newVersion); err != nil {
+			return fmt.Errorf("checking module path: %w", err)
+		}
+	}
+
+	if !config.fullSignatures {
+		currentExported = hashSignatures(currentExported)
+	}
+
+	bump := versionBump(parseVersion(previousState.Version), newVersion)
+	if config.failOn != nil && bump >= *config.failOn {
+		for _, change := range changes {
+			if change.Bump >= *config.failOn {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", change.Bump, change.Reason)
+			}
+		}
+		return fmt.Errorf("%s bump to %s is not allowed by -fail-on %s", bump, newVersion, *config.failOn)
+	}
+	if config.explain {
+		fmt.Fprintln(os.Stderr, explain(parseVersion(previousState.Version), newVersion, changes))
+	}
+	if config.verboseDiff {
+		fmt.Fprint(os.Stderr, verboseDiff(changes))
+	}
+	// Comparing against a base directory or revision never touches the state
+	// file
+	if !config.dryRun && config.base == "" && config.baseRef == "" {
+		newState := State{
+			Version:  newVersion.String(),
+			Exported: currentExported,
+			History:  previousState.nextHistory(config.maxHistory),
+
+			Fingerprint: inputs,
+		}
+
+		if err := saveState(config.stateFile, newState, config.stateFormat, config.compress); err != nil {
+			return fmt.Errorf("saving state: %w", err)
+		}
+	}
+
+	return printVersion(config, newVersion, bump, changes)
+}
+
+// printVersion prints the version, the bump with -bump-only, or a summary of
+// the changes with -summary-json, and writes it to the CI outputs that are
+// asked for.
+func printVersion(config *config, version Version, bump Bump, changes []Change) error {
+	if config.githubOutput {
+		if err := writeGitHubOutput(config.prefix+version.String(), bump); err != nil {
+			return fmt.Errorf("writing GitHub Actions output: %w", err)
+		}
+	}
+	if config.envOut != "" {
+		if err := writeEnvFile(config.envOut, config.prefix+version.String(), bump); err != nil {
+			return fmt.Errorf("writing environment file: %w", err)
+		}
+	}
+
+	if config.summaryJSON {
+		summary, err := json.Marshal(summarize(config.prefix+version.String(), bump, changes))
+		if err != nil {
+			return fmt.Errorf("encoding summary: %w", err)
+		}
+		fmt.Println(string(summary))
+		return nil
+	}
+
+	if config.bumpOnly {
+		fmt.Println(bump)
+		return nil
+	}
+
+	fmt.Println(config.prefix + version.String())
+	return nil
+}
+
+// perPackageConflicts are the flags that need a single version or a single
+// package, so -per-package rejects them rather than ignoring them.
+var perPackageConflicts = []string{
+	"base", "base-ref", "baseline-state", "bump-only", "check-module-path", "commits-since",
+	"env-out", "explain", "file", "force-patch-if-no-symbol-delta", "github-output", "head-ref",
+	"hook", "incremental", "only", "proxy-baseline", "roots", "since", "summary-json",
+	"verbose-diff", "watch",
+}
+
+// config holds the parsed command line flags
+type config struct {
+	dir             string
+	dirs            []string
+	stateFile       string
+	baselineState   string
+	strictParse     bool
+	trackTags       bool
+	trackWellKnown  bool
+	ignoreGenerated bool
+	checkModulePath bool
+	fullSignatures  bool
+	minBump         Bump
+	failOn          *Bump
+	proxyBaseline   bool
+	since           string
+	maxHistory      int
+	commitsSince    string
+	quiet           bool
+	trace           bool
+	recursive       bool
+	includeInternal bool
+	parallel        int
+	strictOrder     bool
+	dryRun          bool
+	incremental     bool
+	force           bool
+	bumpOnly        bool
+	file            string
+	githubOutput    bool
+	envOut          string
+	base            string
+	baseRef         string
+	headRef         string
+	hook            string
+	watch           bool
+	explain         bool
+	verboseDiff     bool
+	listSymbols     bool
+	summaryJSON     bool
+	printConfig     bool
+	cpuProfile      string
+	memProfile      string
+	compress        bool
+	stateFormat     string
+	forcePatch      bool
+	smartWiden      bool
+	positions       bool
+	perPackage      bool
+	roots           []string
+	only            []string
+	initial         string
+	prefix          string
+}
+
+// stateFileName is the name of the state file in -dir or -state-dir
+const stateFileName = "semtype.dat"
+
+// configFileName is the optional file, looked up in the analysis directory,
+// that provides default values for command line flags.
+const configFileName = "semtype.yaml"
+
+func parseFlags() (*config, error) {
+	dir := flag.String("dir", "./", "directory to analyze")
+	stateFile := flag.String("state", "", "path to state file")
+	baselineState := flag.String("baseline-state", "", "compare against this state file, which is never written, instead of -state")
+	strictParse := flag.Bool("strict-parse", false, "fail when any file cannot be parsed")
+	strictOrder := flag.Bool("strict-order", false, "store parameter names, so that swapping parameters of the same type is a major change")
+	trackTags := flag.Bool("track-tags", false, "treat struct tag changes as minor changes")
+	trackWellKnown := flag.Bool("track-well-known-interfaces", false, "report types that start or stop implementing fmt.Stringer, error, and other well-known interfaces")
+	ignoreGenerated := flag.Bool("ignore-generated", false, "skip files marked with a \"// Code generated ... DO NOT EDIT.\" comment")
+	checkModulePath := flag.Bool("check-module-path", false, "fail when the go.mod module path does not match the major version")
+	fullSignatures := flag.Bool("full-signatures", true, "store full signatures in the state file instead of hashes, which make every change to an existing symbol major")
+	minBump := flag.String("min-bump", "patch", "minimum version bump: patch, minor, or major")
+	failOn := flag.String("fail-on", "", "fail without updating the state file when the bump is at least this: patch, minor, or major")
+	proxyBaseline := flag.Bool("proxy-baseline", false, "compare against the latest version published on the module proxy instead of the state file")
+	since := flag.String("since", "", "print the changes since a previous version recorded in the state file")
+	maxHistory := flag.Int("max-history", defaultMaxHistory, "number of previous versions whose exported API is kept in the state file")
+	commitsSince := flag.String("commits-since", "", "raise the bump to at least what conventional commits since a git ref imply")
+	quiet := flag.Bool("quiet", false, "only log errors")
+	trace := flag.Bool("trace", false, "log every file and declaration as it is analyzed or skipped, and why")
+	recursive := flag.Bool("recursive", false, "analyze the packages in every subdirectory")
+	includeInternal := flag.Bool("include-internal", false, "include internal packages when analyzing recursively")
+	parallel := flag.Int("parallel", 0, "number of directories to analyze at once when analyzing recursively, defaults to GOMAXPROCS")
+	dryRun := flag.Bool("dry-run", false, "print the next version without updating the state file")
+	incremental := flag.Bool("incremental", false, "print the recorded version without analyzing when the files are unchanged since it was recorded")
+	force := flag.Bool("force", false, "with -incremental, analyze the package even when its files are unchanged")
+	bumpOnly := flag.Bool("bump-only", false, "print the bump kind, patch, minor, or major, instead of the version")
+	only := flag.String("only", "", "comma separated names or globs, such as Client,*Option, of the only symbols to version")
+	roots := flag.String("roots", "", "comma separated directories, relative to -dir, whose union is the public API")
+	initial := flag.String("initial", "0.0.0", "version to start from when there is no state file")
+	versionFile := flag.String("version-file", "", "file, such as VERSION, with the version to start from when there is no state file, unless -initial is given")
+	prefix := flag.String("prefix", "", "prefix for the printed version, such as v")
+	stateDir := flag.String("state-dir", "", "directory for the state file, instead of -dir, when -state is not given")
+	file := flag.String("file", "", "analyze a single Go file instead of the package in -dir")
+	base := flag.String("base", "", "compare against the package in this directory, at the -initial version, instead of the state file")
+	baseRef := flag.String("base-ref", "", "compare against the package at this git revision, at the version of its closest tag, instead of the state file")
+	headRef := flag.String("head-ref", "", "with -base-ref, analyze the package at this git revision instead of the working tree")
+	hook := flag.String("hook", "", "executable that receives the version and changes as JSON on stdin and prints the version to use")
+	watchFlag := flag.Bool("watch", false, "print the prospective version whenever a Go file changes, without updating the state file")
+	explainFlag := flag.Bool("explain", false, "print a summary of the version decision to stderr")
+	verboseDiffFlag := flag.Bool("verbose-diff", false, "print the previous and current signature of every changed symbol to stderr")
+	listSymbolsFlag := flag.Bool("list-symbols", false, "print every exported symbol with its kind and signature, without versioning")
+	summaryJSON := flag.Bool("summary-json", false, "print the number of added, removed, and changed symbols, the bump, and the version as JSON")
+	printConfigFlag := flag.Bool("print-config", false, "print the value of every flag, after applying the config file, as JSON and exit")
+	perPackage := flag.Bool("per-package", false, "with -recursive, print the version of every package as a line of JSON")
+	forcePatch := flag.Bool("force-patch-if-no-symbol-delta", false, "bump the patch version when the exported symbols are unchanged and their signatures only differ in formatting")
+	smartWiden := flag.Bool("smart-widen", false, "treat a parameter that became an interface satisfied by its previous type as a minor change")
+	positions := flag.Bool("positions", false, "record the source position of every exported symbol in the state and log it with each change")
+	stateFormat := flag.String("state-format", "gob", "encoding of the state file: gob, json, or yaml")
+	compress := flag.Bool("compress", false, "compress the state file with gzip")
+	cpuProfile := flag.String("cpuprofile", "", "write a CPU profile to this file")
+	memProfile := flag.String("memprofile", "", "write a memory profile to this file")
+	githubOutput := flag.Bool("github-output", false, "append the version and bump to the file named by $GITHUB_OUTPUT")
+	envOut := flag.String("env-out", "", "write the version and bump as shell variable assignments to this file")
+	flag.Parse()
+
+	// Several directories are analyzed as one API, with the state and config
+	// files of the first
+	var dirs []string
+	for _, listed := range strings.Split(*dir, ",") {
+		if listed = strings.TrimSpace(listed); listed != "" {
+			dirs = append(dirs, listed)
+		}
+	}
+	if len(dirs) > 0 {
+		*dir = dirs[0]
+	}
+	if len(dirs) < 2 {
+		dirs = nil
+	}
+
+	if *file != "" {
+		fileDir, err := singleFileDir(flag.CommandLine, *file, *dir)
+		if err != nil {
+			return nil, err
+		}
+		*dir = fileDir
+	}
+
+	if err := applyConfigFile(flag.CommandLine, *dir); err != nil {
+		return nil, fmt.Errorf("applying config file: %w", err)
+	}
+
+	bump, err := parseBump(*minBump)
+	if err != nil {
+		return nil, fmt.Errorf("parsing -min-bump: %w", err)
+	}
+
+	if *headRef != "" && *baseRef == "" {
+		return nil, errors.New("-head-ref requires -base-ref")
+	}
+
+	if *perPackage && !*recursive {
+		return nil, errors.New("-per-package requires -recursive")
+	}
+	if *perPackage {
+		var conflicts []string
+		flag.Visit(func(f *flag.Flag) {
+			if slices.Contains(perPackageConflicts, f.Name) {
+				conflicts = append(conflicts, "-"+f.Name)
+			}
+		})
+		if len(conflicts) > 0 {
+			return nil, fmt.Errorf("-per-package cannot be combined with %s", strings.Join(conflicts, ", "))
+		}
+	}
+
+	if len(dirs) > 0 && (*file != "" || *roots != "" || *base != "" || *baseRef != "" || *perPackage) {
+		return nil, errors.New("several directories in -dir cannot be combined with -file, -roots, -base, -base-ref, or -per-package")
+	}
+
+	if !slices.Contains(stateFormats, *stateFormat) {
+		return nil, fmt.Errorf("unknown -state-format %q, expected gob, json, or yaml", *stateFormat)
+	}
+
+	if *maxHistory < 0 {
+		return nil, fmt.Errorf("-max-history must not be negative, got %d", *maxHistory)
+	}
+
+	var failOnBump *Bump
+	if *failOn != "" {
+		bump, err := parseBump(*failOn)
+		if err != nil {
+			return nil, fmt.Errorf("parsing -fail-on: %w", err)
+		}
+		failOnBump = &bump
+	}
+
+	var rootDirs []string
+	for _, root := range strings.Split(*roots, ",") {
+		if root = strings.TrimSpace(root); root != "" {
+			rootDirs = append(rootDirs, root)
+		}
+	}
+
+	var patterns []string
+	for _, pattern := range strings.Split(*only, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("parsing -only pattern %q: %w", pattern, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	if *stateFile == "" {
+		// The state file is not a Go file, so it is never analyzed even when
+		// the state directory is within the analyzed tree
+		*stateFile = filepath.Join(*dir, stateFileName)
+		if *stateDir != "" {
+			*stateFile = filepath.Join(*stateDir, stateFileName)
+		}
+	}
+
+	if *baselineState != "" && filepath.Clean(*baselineState) == filepath.Clean(*stateFile) {
+		return nil, errors.New("-baseline-state must differ from -state, as it is never written")
+	}
+
+	if *versionFile != "" {
+		version, err := initialVersion(flag.CommandLine, *versionFile, *stateFile, *initial)
+		if err != nil {
+			return nil, err
+		}
+		*initial = version
+	}
+
+	return &config{
+		dir:             *dir,
+		dirs:            dirs,
+		stateFile:       *stateFile,
+		baselineState:   *baselineState,
+		strictParse:     *strictParse,
+		trackTags:       *trackTags,
+		trackWellKnown:  *trackWellKnown,
+		ignoreGenerated: *ignoreGenerated,
+		strictOrder:     *strictOrder,
+		checkModulePath: *checkModulePath,
+		fullSignatures:  *fullSignatures,
+		minBump:         bump,
+		failOn:          failOnBump,
+		proxyBaseline:   *proxyBaseline,
+		since:           *since,
+		maxHistory:      *maxHistory,
+		commitsSince:    *commitsSince,
+		quiet:           *quiet,
+		trace:           *trace,
+		recursive:       *recursive,
+		includeInternal: *includeInternal,
+		parallel:        *parallel,
+		dryRun:          *dryRun,
+		incremental:     *incremental,
+		force:           *force,
+		bumpOnly:        *bumpOnly,
+		file:            *file,
+		githubOutput:    *githubOutput,
+		envOut:          *envOut,
+		base:            *base,
+		baseRef:         *baseRef,
+		headRef:         *headRef,
+		hook:            *hook,
+		watch:           *watchFlag,
+		explain:         *explainFlag,
+		verboseDiff:     *verboseDiffFlag,
+		listSymbols:     *listSymbolsFlag,
+		summaryJSON:     *summaryJSON,
+		printConfig:     *printConfigFlag,
+		cpuProfile:      *cpuProfile,
+		memProfile:      *memProfile,
+		compress:        *compress,
+		stateFormat:     *stateFormat,
+		forcePatch:      *forcePatch,
+		smartWiden:      *smartWiden,
+		positions:       *positions,
+		perPackage:      *perPackage,
+		roots:           rootDirs,
+		only:            patterns,
+		initial:         *initial,
+		prefix:          *prefix,
+	}, nil
+}
+
+// singleFileDir returns the directory of the file given with -file, which
+// replaces -dir as the location of the state and config files. A -dir that
+// names another directory would analyze a different package, so it fails.
+func singleFileDir(flags *flag.FlagSet, file, dir string) (string, error) {
+	fileDir := filepath.Dir(file)
+
+	dirSet := false
+	flags.Visit(func(f *flag.Flag) {
+		dirSet = dirSet || f.Name == "dir"
+	})
+	if !dirSet {
+		return fileDir, nil
+	}
+
+	absFileDir, err := filepath.Abs(fileDir)
+	if err != nil {
+		return "", fmt.Errorf("resolving -file: %w", err)
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("resolving -dir: %w", err)
+	}
+	if absFileDir != absDir {
+		return "", fmt.Errorf("-file %s is not part of the package in -dir %s", file, dir)
+	}
+
+	return dir, nil
+}
+
+// initialVersion returns the version in the version file when there is no
+// state file yet to take the version from, and -initial was not given.
+func initialVersion(flags *flag.FlagSet, versionFile, stateFile, initial string) (string, error) {
+	initialSet := false
+	flags.Visit(func(f *flag.Flag) {
+		initialSet = initialSet || f.Name == "initial"
+	})
+	if initialSet {
+		return initial, nil
+	}
+	if _, err := os.Stat(stateFile); !os.IsNotExist(err) {
+		return initial, nil
+	}
+
+	contents, err := os.ReadFile(versionFile)
+	if err != nil {
+		return "", fmt.Errorf("reading -version-file: %w", err)
+	}
+	return strings.TrimSpace(string(contents)), nil
+}
+
+// printConfig prints the effective value of every flag, keyed by its name as
+// in the config file, such as to debug where a setting comes from.
+func printConfig(flags *flag.FlagSet) error {
+	values := make(map[string]any)
+	flags.VisitAll(func(f *flag.Flag) {
+		if f.Name == "print-config" {
+			return
+		}
+		if getter, ok := f.Value.(flag.Getter); ok {
+			values[f.Name] = getter.Get()
+		} else {
+			values[f.Name] = f.Value.String()
+		}
+	})
+
+	contents, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding config: %w", err)
+	}
+	fmt.Println(string(contents))
+	return nil
+}
+
+// applyConfigFile sets every flag that was not given on the command line to
+// the value found in the config file, if one exists. A list is joined with
+// commas, as taken by flags such as -only.
+func applyConfigFile(flags *flag.FlagSet, dir string) error {
+	configPath := filepath.Join(dir, configFileName)
+
+	contents, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("reading %s: %w", configPath, err)
+	}
+
+	var values map[string]any
+	if err := yaml.Unmarshal(contents, &values); err != nil {
+		return fmt.Errorf("parsing %s: %w", configPath, err)
+	}
+
+	explicit := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Every key is checked before any is applied, so that a misspelled key
+	// is reported even when another value is invalid
+	var unknown []string
+	for _, name := range names {
+		if flags.Lookup(name) != nil {
+			continue
+		}
+		if suggestion := strings.ToLower(strings.ReplaceAll(name, "_", "-")); flags.Lookup(suggestion) != nil {
+			unknown = append(unknown, fmt.Sprintf("unknown option %q, did you mean %q", name, suggestion))
+		} else {
+			unknown = append(unknown, fmt.Sprintf("unknown option %q", name))
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("parsing %s: %s", configPath, strings.Join(unknown, "; "))
+	}
+
+	for _, name := range names {
+		if name == "dir" || name == "file" {
+			// The config file is looked up in the directory, once it is known
+			return fmt.Errorf("parsing %s: option %q can only be given on the command line", configPath, name)
+		}
+		if explicit[name] {
+			continue
+		}
+		value, err := configValue(values[name])
+		if err != nil {
+			return fmt.Errorf("parsing %s: invalid value for %q: %w", configPath, name, err)
+		}
+		if err := flags.Set(name, value); err != nil {
+			return fmt.Errorf("parsing %s: invalid value for %q: %w", configPath, name, err)
+		}
+	}
+
+	return nil
+}
+
+// configValue formats a value of the config file as a flag value, joining
+// the elements of a list with commas
+func configValue(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case []any:
+		elements := make([]string, 0, len(v))
+		for _, element := range v {
+			if _, nested := element.([]any); nested {
+				return "", errors.New("expected a list of values, not of lists")
+			}
+			formatted, err := configValue(element)
+			if err != nil {
+				return "", err
+			}
+			elements = append(elements, formatted)
+		}
+		return strings.Join(elements, ","), nil
+	case map[string]any:
+		return "", errors.New("expected a value or a list of values, not a mapping")
+	}
+	return fmt.Sprint(value), nil
+}
+
+// writeGitHubOutput appends the version and bump as GitHub Actions step
+// outputs, to the file named by GITHUB_OUTPUT.
+func writeGitHubOutput(version string, bump Bump) error {
+	outputPath := os.Getenv("GITHUB_OUTPUT")
+	if outputPath == "" {
+		return fmt.Errorf("GITHUB_OUTPUT is not set")
+	}
+
+	file, err := os.OpenFile(outputPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", outputPath, err)
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil {
+			slog.Warn("failed to close GitHub Actions output", "error", closeErr)
+		}
+	}()
+
+	if _, err := fmt.Fprintf(file, "version=%s\nbump=%s\n", version, bump); err != nil {
+		return fmt.Errorf("writing %s: %w", outputPath, err)
+	}
+
+	return nil
+}
+
+// writeEnvFile writes the version and bump as SEMTYPE_VERSION and
+// SEMTYPE_BUMP assignments, which a shell can source in later CI steps.
+func writeEnvFile(envPath, version string, bump Bump) error {
+	contents := fmt.Sprintf("SEMTYPE_VERSION=%s\nSEMTYPE_BUMP=%s\n", shellQuote(version), shellQuote(bump.String()))
+	if err := os.WriteFile(envPath, []byte(contents), 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", envPath, err)
+	}
+
+	return nil
+}
+
+// shellQuote quotes a value for a POSIX shell, so that a prefix or build
+// metadata from a hook cannot be expanded or run.
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// loadState reads the state file, or starts from the initial version with no
+// exported symbols when there is none.
+func loadState(stateFile, initial string) (State, error) {
+	file, err := os.Open(stateFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return State{Version: parseVersion(initial).String(), Exported: newExported()}, nil
+		}
+		return State{}, fmt.Errorf("opening state file: %w", err)
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil {
+			slog.Warn("failed to close state file", "error", closeErr)
+		}
+	}()
+
+	// Compressed state files are told apart by the gzip magic bytes
+	buffered := bufio.NewReader(file)
+	var reader io.Reader = buffered
+	if magic, err := buffered.Peek(len(gzipMagic)); err == nil && bytes.Equal(magic, gzipMagic) {
+		gzipReader, err := gzip.NewReader(buffered)
+		if err != nil {
+			return State{}, markError(ErrStateCorrupt, fmt.Errorf("decompressing state file: %w", err))
+		}
+		reader = gzipReader
+	}
+
+	contents, err := io.ReadAll(reader)
+	if err != nil {
+		return State{}, markError(ErrStateCorrupt, fmt.Errorf("reading state file: %w", err))
+	}
+	state, err := decodeState(contents)
+	if err != nil {
+		return State{}, markError(ErrStateCorrupt, fmt.Errorf("decoding state file: %w", err))
+	}
+
+	return state, nil
+}
+
+func saveState(stateFile string, state State, format string, compress bool) error {
+	if err := os.MkdirAll(filepath.Dir(stateFile), os.ModePerm); err != nil {
+		return fmt.Errorf("creating state directory: %w", err)
+	}
+
+	return writeFileAtomic(stateFile, func(w io.Writer) error {
+		if !compress {
+			if err := encodeState(w, state, format); err != nil {
+				return fmt.Errorf("encoding state: %w", err)
+			}
+			return nil
+		}
+
+		gzipWriter := gzip.NewWriter(w)
+		if err := encodeState(gzipWriter, state, format); err != nil {
+			return fmt.Errorf("encoding state: %w", err)
+		}
+		if err := gzipWriter.Close(); err != nil {
+			return fmt.Errorf("compressing state: %w", err)
+		}
+		return nil
+	})
+}
+
+// gzipMagic starts every gzip stream
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// writeFileAtomic writes a temporary file next to path and renames it into
+// place once it is complete and synced, so that a failed or interrupted write
+// leaves the previous file intact.
+func writeFileAtomic(path string, write func(io.Writer) error) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return fmt.Errorf("creating state file: %w", err)
+	}
+
+	tempPath := file.Name()
+	renamed := false
+	defer func() {
+		if renamed {
+			return
+		}
+		if closeErr := file.Close(); closeErr != nil && !errors.Is(closeErr, os.ErrClosed) {
+			slog.Warn("failed to close state file", "error", closeErr)
+		}
+		if removeErr := os.Remove(tempPath); removeErr != nil {
+			slog.Warn("failed to remove temporary state file", "file", tempPath, "error", removeErr)
+		}
+	}()
+
+	// Temporary files are private, unlike the state file they replace
+	if err := file.Chmod(0o644); err != nil {
+		return fmt.Errorf("setting state file permissions: %w", err)
+	}
+	if err := write(file); err != nil {
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("syncing state file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("closing state file: %w", err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		return fmt.Errorf("replacing state file: %w", err)
+	}
+
+	renamed = true
+	return nil
+}
+
+// analyzeOptions controls how the source files of a package are analyzed
+type analyzeOptions struct {
+	strictParse     bool
+	trackTags       bool
+	recursive       bool
+	includeInternal bool
+	parallel        int
+	strictOrder     bool
+	trackWellKnown  bool
+	ignoreGenerated bool
+}
+
+// hashPrefix marks a signature that is stored as a hash instead of its text
+const hashPrefix = "sha256:"
+
+// hashSignature returns a stable hash of a formatted signature
+func hashSignature(signature string) string {
+	if strings.HasPrefix(signature, hashPrefix) {
+		return signature
+	}
+	sum := sha256.Sum256([]byte(signature))
+	return hashPrefix + hex.EncodeToString(sum[:])
+}
+
+// hashSignatures replaces every signature with its hash, so the state file
+// does not carry the source of the API.
+func hashSignatures(exported Exported) Exported {
+	return Exported{
+		Types:      hashSignatureMap(exported.Types),
+		Functions:  hashSignatureMap(exported.Functions),
+		Methods:    hashSignatureMap(exported.Methods),
+		Constants:  hashSignatureMap(exported.Constants),
+		Deprecated: exported.Deprecated,
+		Packages:   exported.Packages,
+		Comparable: exported.Comparable,
+
+		PromotedFields: hashSignatureMap(exported.PromotedFields),
+		Implements:     exported.Implements,
+		WellKnown:      exported.WellKnown,
+		TypeParams:     hashSignatureMap(exported.TypeParams),
+		Variables:      hashSignatureMap(exported.Variables),
+		Positions:      exported.Positions,
+	}
+}
+
+func hashSignatureMap(signatures map[string]string) map[string]string {
+	hashed := make(map[string]string, len(signatures))
+	for name, signature := range signatures {
+		hashed[name] = hashSignature(signature)
+	}
+	return hashed
+}
+
+// analyzeRoots analyzes the union of the root directories, relative to dir,
+// that make up the public API. Symbols are qualified by their root, and dir
+// itself is analyzed when no roots are given.
+func analyzeRoots(dir string, roots []string, options analyzeOptions) (Exported, error) {
+	if len(roots) == 0 {
+		return analyzePackage(dir, options)
+	}
+
+	exported := newExported()
+	for _, root := range roots {
+		rootExported, err := analyzePackage(filepath.Join(dir, root), options)
+		if err != nil && !errors.Is(err, ErrNoSources) {
+			return exported, fmt.Errorf("analyzing root %s: %w", root, err)
+		}
+		exported.merge(rootExported, filepath.ToSlash(filepath.Clean(root))+".")
+	}
+
+	return exported, requireSources(exported, dir)
+}
+
+// analyzeDirList analyzes the directories as one API, with the symbols of
+// each named after it, such as "pkg/a.Type".
+func analyzeDirList(dirs []string, options analyzeOptions) (Exported, error) {
+	exported := newExported()
+	for _, dir := range dirs {
+		dirExported, err := analyzePackage(dir, options)
+		if err != nil && !errors.Is(err, ErrNoSources) {
+			return exported, fmt.Errorf("analyzing %s: %w", dir, err)
+		}
+		exported.merge(dirExported, filepath.ToSlash(filepath.Clean(dir))+".")
+	}
+
+	return exported, requireSources(exported, strings.Join(dirs, ","))
+}
+
+func analyzePackage(dir string, options analyzeOptions) (Exported, error) {
+	if !options.recursive {
+		exported, err := analyzeDir(dir, options)
+		if err != nil {
+			return exported, err
+		}
+		return exported, requireSources(exported, dir)
+	}
+
+	packages, err := packageDirs(dir, options)
+	if err != nil {
+		return newExported(), err
+	}
+
+	exported, err := analyzeDirs(packages, options)
+	if err != nil {
+		return exported, err
+	}
+
+	return exported, requireSources(exported, dir)
+}
+
+// packageDir is a directory to analyze, and the prefix of its symbols
+type packageDir struct {
+	path   string
+	prefix string
+}
+
+// packageDirs returns dir and every subdirectory that may hold a package of
+// the API. Symbols of nested packages are qualified by their directory.
+func packageDirs(dir string, options analyzeOptions) ([]packageDir, error) {
+	var packages []packageDir
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			packages = append(packages, packageDir{path: path})
+			return nil
+		}
+		if skipDir(entry.Name(), options) {
+			return filepath.SkipDir
+		}
+		packages = append(packages, packageDir{path: path, prefix: filepath.ToSlash(rel) + "."})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walking directory: %w", err)
+	}
+	return packages, nil
+}
+
+// analyzeDirs analyzes the directories and merges the results in their
+// order, so that the first error reported does not depend on scheduling.
+func analyzeDirs(packages []packageDir, options analyzeOptions) (Exported, error) {
+	results, err := analyzeEach(packages, options)
+	if err != nil {
+		return newExported(), err
+	}
+
+	exported := newExported()
+	for i, pkg := range packages {
+		exported.merge(results[i], pkg.prefix)
+	}
+	return exported, nil
+}
+
+// analyzeEach analyzes every directory on its own, with up to
+// options.parallel workers.
+func analyzeEach(packages []packageDir, options analyzeOptions) ([]Exported, error) {
+	workers := options.parallel
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	results := make([]Exported, len(packages))
+	errs := make([]error, len(packages))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(workers, len(packages)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i], errs[i] = analyzeDir(packages[i].path, options)
+			}
+		}()
+	}
+	for i := range packages {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	for i, pkg := range packages {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("analyzing %s: %w", pkg.path, errs[i])
+		}
+	}
+	return results, nil
+}
+
+// requireSources fails when no package was found, as an empty API is more
+// likely a wrong directory than a release without any Go files.
+func requireSources(exported Exported, dir string) error {
+	if len(exported.Packages) == 0 {
+		return fmt.Errorf("%w in %s", ErrNoSources, dir)
+	}
+	return nil
+}
+
+// skipDir reports whether a directory is ignored when recursing, following
+// the rules of the go command. Internal packages are not part of the public
+// API, so they are skipped unless asked for.
+func skipDir(name string, options analyzeOptions) bool {
+	switch {
+	case strings.HasPrefix(name, "."), strings.HasPrefix(name, "_"):
+		return true
+	case name == "testdata", name == "vendor":
+		return true
+	case name == "internal":
+		return !options.includeInternal
+	}
+	return false
+}
+
+// merge adds the symbols of another package, with their names prefixed
+func (e Exported) merge(other Exported, prefix string) {
+	for name, signature := range other.Types {
+		e.Types[prefix+name] = signature
+	}
+	for name, signature := range other.Functions {
+		e.Functions[prefix+name] = signature
+	}
+	for name, signature := range other.Methods {
+		e.Methods[prefix+name] = signature
+	}
+	for name, signature := range other.Constants {
+		e.Constants[prefix+name] = signature
+	}
+	for name, signature := range other.Variables {
+		e.Variables[prefix+name] = signature
+	}
+	for name := range other.Deprecated {
+		e.Deprecated[prefix+name] = true
+	}
+	for name, signature := range other.PromotedFields {
+		e.PromotedFields[prefix+name] = signature
+	}
+	for name, typeParams := range other.TypeParams {
+		e.TypeParams[prefix+name] = typeParams
+	}
+	for name, interfaces := range other.Implements {
+		for _, interfaceName := range interfaces {
+			e.Implements[prefix+name] = append(e.Implements[prefix+name], prefix+interfaceName)
+		}
+	}
+	for name, interfaces := range other.WellKnown {
+		e.WellKnown[prefix+name] = interfaces
+	}
+	for name := range other.Comparable {
+		e.Comparable[prefix+name] = true
+	}
+	for name, position := range other.Positions {
+		position.Filename = path.Join(strings.TrimSuffix(prefix, "."), position.Filename)
+		e.Positions[prefix+name] = position
+	}
+	for name := range other.Packages {
+		if name == "" {
+			e.Packages[strings.TrimSuffix(prefix, ".")] = true
+		} else {
+			e.Packages[prefix+name] = true
+		}
+	}
+}
+
+// only returns the symbols whose name, without the package prefix, matches
+// one of the patterns. Methods and fields are kept along with their type.
+func (e Exported) only(patterns []string) Exported {
+	matches := func(name string) bool {
+		name = name[strings.LastIndex(name, ".")+1:]
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(pattern, name); matched {
+				return true
+			}
+		}
+		return false
+	}
+	typeMatches := func(name string) bool {
+		return matches(name[:max(strings.LastIndex(name, "."), 0)])
+	}
 
-	if err := run(); err != nil {
-		slog.Error("execution failed", "error", err)
-		os.Exit(1)
+	filtered := newExported()
+	filtered.Packages = e.Packages
+	for name, signature := range e.Types {
+		if matches(name) {
+			filtered.Types[name] = signature
+		}
+	}
+	for name, signature := range e.Functions {
+		if matches(name) {
+			filtered.Functions[name] = signature
+		}
+	}
+	for name, signature := range e.Methods {
+		if typeMatches(name) {
+			filtered.Methods[name] = signature
+		}
+	}
+	for name, signature := range e.Constants {
+		if matches(name) {
+			filtered.Constants[name] = signature
+		}
+	}
+	for name, signature := range e.Variables {
+		if matches(name) {
+			filtered.Variables[name] = signature
+		}
+	}
+	for name := range e.Comparable {
+		if matches(name) {
+			filtered.Comparable[name] = true
+		}
 	}
+	for name, signature := range e.PromotedFields {
+		if typeMatches(name) {
+			filtered.PromotedFields[name] = signature
+		}
+	}
+	for name, interfaces := range e.Implements {
+		if matches(name) {
+			filtered.Implements[name] = interfaces
+		}
+	}
+	for name, interfaces := range e.WellKnown {
+		if matches(name) {
+			filtered.WellKnown[name] = interfaces
+		}
+	}
+	for name, typeParams := range e.TypeParams {
+		if matches(name) {
+			filtered.TypeParams[name] = typeParams
+		}
+	}
+	for name := range e.Deprecated {
+		if hasKey(filtered.Types, name) || hasKey(filtered.Functions, name) || hasKey(filtered.Methods, name) || hasKey(filtered.Constants, name) || hasKey(filtered.Variables, name) {
+			filtered.Deprecated[name] = true
+		}
+	}
+	for name, position := range e.Positions {
+		if symbolKind(filtered, name) != "" {
+			filtered.Positions[name] = position
+		}
+	}
+	return filtered
 }
 
-func run() error {
-	config, err := parseFlags()
-	if err != nil {
-		return fmt.Errorf("parsing flags: %w", err)
-	}
+// analyzeDir analyzes the Go files of a single directory
+func analyzeDir(dir string, options analyzeOptions) (Exported, error) {
+	exported := newExported()
 
-	previousState, err := loadState(config.stateFile)
+	fset := token.NewFileSet()
+	files, err := parseDir(fset, dir, options)
 	if err != nil {
-		return fmt.Errorf("loading state: %w", err)
+		return exported, fmt.Errorf("parsing directory: %w", err)
 	}
 
-	currentExported, err := analyzePackage(config.dir)
-	if err != nil {
-		return fmt.Errorf("analyzing package: %w", err)
+	if len(files) > 0 {
+		exported.Packages[""] = true
 	}
+	if err := analyzePackageFiles(fset, files, options, &exported); err != nil {
+		return exported, err
+	}
+
+	return exported, nil
+}
 
-	newVersion := calculateVersion(previousState, currentExported)
+// AnalyzeFiles analyzes the package made of the in-memory Go files, keyed by
+// file name, so that tests can analyze sources without writing them to disk.
+// Like a directory, files that fail to parse are skipped, and names without a
+// .go extension ignored.
+func AnalyzeFiles(sources map[string]string) (Exported, error) {
+	exported := newExported()
+	options := analyzeOptions{}
 
-	newState := State{
-		Version:  newVersion.String(),
-		Exported: currentExported,
+	fset := token.NewFileSet()
+	files := make(map[string]*ast.File)
+	for _, filename := range sortedKeys(sources) {
+		if filepath.Ext(filename) != ".go" {
+			continue
+		}
+
+		file, err := parser.ParseFile(fset, filename, sources[filename], parser.ParseComments)
+		if err != nil {
+			slog.Warn("skipping file that failed to parse", "file", filename, "error", err)
+			continue
+		}
+		files[filename] = file
 	}
 
-	if err := saveState(config.stateFile, newState); err != nil {
-		return fmt.Errorf("saving state: %w", err)
+	if len(files) > 0 {
+		exported.Packages[""] = true
+	}
+	if err := analyzePackageFiles(fset, files, options, &exported); err != nil {
+		return exported, err
 	}
 
-	fmt.Println(newVersion.String())
-	return nil
+	return exported, nil
 }
 
-// config holds the parsed command line flags
-type config struct {
-	dir       string
-	stateFile string
-}
+// analyzeFile analyzes a single Go file as if it were the whole package
+func analyzeFile(filename string, options analyzeOptions) (Exported, error) {
+	exported := newExported()
 
-func parseFlags() (*config, error) {
-	dir := flag.String("dir", "./", "directory to analyze")
-	stateFile := flag.String("state", "", "path to state file")
-	flag.Parse()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, nil, parser.ParseComments)
+	if err != nil {
+		return exported, markError(ErrParse, fmt.Errorf("parsing file: %w", err))
+	}
 
-	if *stateFile == "" {
-		*stateFile = filepath.Join(*dir, "semtype.dat")
+	exported.Packages[""] = true
+	if err := analyzePackageFiles(fset, map[string]*ast.File{filename: file}, options, &exported); err != nil {
+		return exported, err
 	}
 
-	return &config{
-		dir:       *dir,
-		stateFile: *stateFile,
-	}, nil
+	return exported, nil
 }
 
-func loadState(stateFile string) (State, error) {
-	file, err := os.Open(stateFile)
+// parseDir parses every Go file in dir. Files that fail to parse are skipped
+// with a warning, unless strict parsing is requested.
+func parseDir(fset *token.FileSet, dir string, options analyzeOptions) (map[string]*ast.File, error) {
+	entries, err := os.ReadDir(dir)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return State{Version: "0.0.0", Exported: Exported{
-				Types:     make(map[string]string),
-				Functions: make(map[string]string),
-			}}, nil
-		}
-		return State{}, fmt.Errorf("opening state file: %w", err)
+		return nil, fmt.Errorf("reading directory: %w", err)
 	}
-	defer func() {
-		if closeErr := file.Close(); closeErr != nil {
-			slog.Warn("failed to close state file", "error", closeErr)
+
+	files := make(map[string]*ast.File)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".go" {
+			slog.Debug("skipping file", "file", filepath.Join(dir, entry.Name()), "reason", "not a Go file")
+			continue
 		}
-	}()
 
-	var state State
-	decoder := gob.NewDecoder(file)
-	if err := decoder.Decode(&state); err != nil {
-		return State{}, fmt.Errorf("decoding state file: %w", err)
+		filename := filepath.Join(dir, entry.Name())
+		file, err := parser.ParseFile(fset, filename, nil, parser.ParseComments)
+		if err != nil {
+			if options.strictParse {
+				return nil, markError(ErrParse, err)
+			}
+			slog.Warn("skipping file that failed to parse", "file", filename, "error", err)
+			continue
+		}
+		files[filename] = file
 	}
 
-	return state, nil
+	return files, nil
 }
 
-func saveState(stateFile string, state State) error {
-	file, err := os.Create(stateFile)
-	if err != nil {
-		return fmt.Errorf("creating state file: %w", err)
+// analyzePackageFiles records the exported API of the parsed files of a
+// package. The analysis only relies on the syntax, without type checking, so
+// packages that do not compile, such as on a branch still in progress, are
+// analyzed all the same.
+func analyzePackageFiles(fset *token.FileSet, files map[string]*ast.File, options analyzeOptions, exported *Exported) error {
+	if options.ignoreGenerated {
+		files = withoutGenerated(files)
 	}
-	defer func() {
-		if closeErr := file.Close(); closeErr != nil {
-			slog.Warn("failed to close state file", "error", closeErr)
+
+	// Every file is qualified before any is analyzed, as the interfaces and
+	// structs of one file are expanded into the signatures of another
+	for filename, file := range files {
+		dir, err := filepath.Abs(filepath.Dir(filename))
+		if err != nil {
+			return fmt.Errorf("finding directory of %s: %w", filename, err)
 		}
-	}()
+		qualifyImports(file, dir)
+	}
+
+	interfaces := newInterfaceSet(files)
+	for filename, file := range files {
+		slog.Debug("analyzing file", "file", filename)
 
-	encoder := gob.NewEncoder(file)
-	if err := encoder.Encode(&state); err != nil {
-		return fmt.Errorf("encoding state: %w", err)
+		for _, decl := range file.Decls {
+			switch d := decl.(type) {
+			case *ast.GenDecl:
+				if err := analyzeGenDecl(d, interfaces, options, exported); err != nil {
+					return err
+				}
+			case *ast.FuncDecl:
+				if err := analyzeFuncDecl(d, interfaces, options, exported); err != nil {
+					return err
+				}
+			}
+		}
 	}
 
-	return nil
-}
+	analyzeConstants(newConstEvaluator(files), exported)
 
-func analyzePackage(dir string) (Exported, error) {
-	exported := Exported{
-		Types:     make(map[string]string),
-		Functions: make(map[string]string),
+	for name := range newTypeDecls(files).comparableStructs() {
+		exported.Comparable[name] = true
 	}
 
-	fset := token.NewFileSet()
-	pkgs, err := parser.ParseDir(fset, dir, nil, parser.ParseComments)
-	if err != nil {
-		return exported, fmt.Errorf("parsing directory: %w", err)
+	methods, fields := newPromoter(files, interfaces).promotions()
+	for name, funcType := range methods {
+		if _, declared := exported.Methods[name]; declared {
+			continue
+		}
+		formatted, err := formatNode(funcType)
+		if err != nil {
+			slog.Warn("failed to format promoted method", "name", name, "error", err)
+			continue
+		}
+		exported.Methods[name] = formatted
+	}
+	for name, fieldType := range fields {
+		formatted, err := formatNode(fieldType)
+		if err != nil {
+			slog.Warn("failed to format promoted field", "name", name, "error", err)
+			continue
+		}
+		exported.PromotedFields[name] = formatted
 	}
 
-	for _, pkg := range pkgs {
-		if err := analyzePackageFiles(fset, pkg.Files, &exported); err != nil {
-			return exported, err
+	for name, interfaces := range implementations(*exported) {
+		exported.Implements[name] = interfaces
+	}
+	if options.trackWellKnown {
+		for name, interfaces := range wellKnownImplementations(*exported) {
+			exported.WellKnown[name] = interfaces
 		}
 	}
 
-	return exported, nil
+	recordPositions(fset, files, exported)
+	return nil
+}
+
+// withoutGenerated drops the files marked as generated. Following the Go
+// convention, that is a line matching `^// Code generated .* DO NOT EDIT\.$`
+// before the package clause, which go/ast checks.
+func withoutGenerated(files map[string]*ast.File) map[string]*ast.File {
+	kept := make(map[string]*ast.File, len(files))
+	for filename, file := range files {
+		if ast.IsGenerated(file) {
+			slog.Debug("skipping file", "file", filename, "reason", "generated")
+			continue
+		}
+		kept[filename] = file
+	}
+	return kept
 }
 
-func analyzePackageFiles(fset *token.FileSet, files map[string]*ast.File, exported *Exported) error {
+// recordPositions records where every recorded symbol is declared, with the
+// file name alone, as the files of a package share a directory.
+func recordPositions(fset *token.FileSet, files map[string]*ast.File, exported *Exported) {
+	record := func(symbols map[string]string, name string, pos token.Pos) {
+		if !hasKey(symbols, name) {
+			return
+		}
+		position := fset.Position(pos)
+		position.Filename = filepath.Base(position.Filename)
+		exported.Positions[name] = position
+	}
+
 	for _, file := range files {
 		for _, decl := range file.Decls {
 			switch d := decl.(type) {
 			case *ast.GenDecl:
-				if err := analyzeGenDecl(fset, d, exported); err != nil {
-					return err
+				for _, spec := range d.Specs {
+					switch s := spec.(type) {
+					case *ast.TypeSpec:
+						record(exported.Types, s.Name.Name, s.Name.Pos())
+					case *ast.ValueSpec:
+						symbols := exported.Variables
+						if d.Tok == token.CONST {
+							symbols = exported.Constants
+						}
+						for _, name := range s.Names {
+							record(symbols, name.Name, name.Pos())
+						}
+					}
 				}
 			case *ast.FuncDecl:
-				if err := analyzeFuncDecl(fset, d, exported); err != nil {
-					return err
+				if d.Recv == nil {
+					record(exported.Functions, d.Name.Name, d.Name.Pos())
+				} else {
+					record(exported.Methods, receiverTypeName(d.Recv)+"."+d.Name.Name, d.Name.Pos())
 				}
 			}
 		}
 	}
-	return nil
 }
 
-func analyzeGenDecl(fset *token.FileSet, d *ast.GenDecl, exported *Exported) error {
+// analyzeConstants records the type and resolved value of every exported
+// constant, so that a changed value, such as a reordered iota, is detected.
+func analyzeConstants(evaluator *constEvaluator, exported *Exported) {
+	for name, spec := range evaluator.specs {
+		if !spec.name.IsExported() {
+			slog.Debug("skipping constant", "name", name, "reason", "unexported")
+			continue
+		}
+		slog.Debug("recording constant", "name", name)
+
+		typ := ""
+		if spec.typ != nil {
+			formatted, err := formatNode(spec.typ)
+			if err != nil {
+				slog.Warn("failed to format constant", "name", name, "error", err)
+				continue
+			}
+			typ = formatted + " "
+		}
+
+		value, _ := evaluator.resolve(name)
+		exported.Constants[name] = typ + "= " + formatConstValue(value, spec.value, spec.iota)
+
+		if isDeprecated(spec.doc...) {
+			exported.Deprecated[name] = true
+		}
+	}
+}
+
+// aliasPrefix marks the signature of an alias, such as "= log.Logger" for
+// "type Logger = log.Logger"
+const aliasPrefix = "= "
+
+func analyzeGenDecl(d *ast.GenDecl, interfaces interfaceSet, options analyzeOptions, exported *Exported) error {
 	for _, spec := range d.Specs {
+		if typeSpec, ok := spec.(*ast.TypeSpec); ok && !typeSpec.Name.IsExported() {
+			slog.Debug("skipping type", "name", typeSpec.Name.Name, "reason", "unexported")
+			continue
+		}
 		if typeSpec, ok := spec.(*ast.TypeSpec); ok && typeSpec.Name.IsExported() {
-			simplified := simplifyType(typeSpec.Type)
-			formatted, err := formatNode(fset, simplified)
+			slog.Debug("recording type", "name", typeSpec.Name.Name)
+			simplified := simplifyType(typeSpec.Type, interfaces, options)
+			formatted, err := formatNode(simplified)
 			if err != nil {
 				slog.Warn("failed to format type", "name", typeSpec.Name.Name, "error", err)
 				continue
 			}
+			if typeSpec.Assign.IsValid() {
+				formatted = aliasPrefix + formatted
+			}
 			exported.Types[typeSpec.Name.Name] = formatted
+
+			if typeSpec.TypeParams != nil {
+				names, constraints := typeParamList(expandConstraints(typeSpec.TypeParams, interfaces))
+				exported.TypeParams[typeSpec.Name.Name] = formatTypeParams(names, constraints)
+			}
+
+			if isDeprecated(typeSpec.Doc, d.Doc) {
+				exported.Deprecated[typeSpec.Name.Name] = true
+			}
+		}
+
+		if valueSpec, ok := spec.(*ast.ValueSpec); ok && d.Tok == token.VAR {
+			for i, name := range valueSpec.Names {
+				if !name.IsExported() {
+					slog.Debug("skipping variable", "name", name.Name, "reason", "unexported")
+					continue
+				}
+				slog.Debug("recording variable", "name", name.Name)
+
+				formatted := ""
+				if typ := varType(valueSpec, i); typ != nil {
+					var err error
+					if formatted, err = formatNode(stripFuncNames(typ)); err != nil {
+						slog.Warn("failed to format variable", "name", name.Name, "error", err)
+						continue
+					}
+				}
+				exported.Variables[name.Name] = formatted
+
+				if isDeprecated(valueSpec.Doc, d.Doc) {
+					exported.Deprecated[name.Name] = true
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// varType returns the type of the i-th variable of the declaration, either
+// declared or obvious from its value, such as "*T" for "&T{}", or nil.
+func varType(spec *ast.ValueSpec, i int) ast.Expr {
+	if spec.Type != nil {
+		return spec.Type
+	}
+	if len(spec.Values) != len(spec.Names) {
+		return nil
+	}
+
+	switch value := spec.Values[i].(type) {
+	case *ast.CompositeLit:
+		return value.Type
+	case *ast.FuncLit:
+		return value.Type
+	case *ast.UnaryExpr:
+		if lit, ok := value.X.(*ast.CompositeLit); ok && value.Op == token.AND && lit.Type != nil {
+			return &ast.StarExpr{X: lit.Type}
+		}
+	case *ast.BasicLit:
+		// The default types of untyped constants
+		switch value.Kind {
+		case token.INT:
+			return ast.NewIdent("int")
+		case token.FLOAT:
+			return ast.NewIdent("float64")
+		case token.IMAG:
+			return ast.NewIdent("complex128")
+		case token.CHAR:
+			return ast.NewIdent("rune")
+		case token.STRING:
+			return ast.NewIdent("string")
+		}
+	case *ast.Ident:
+		if value.Name == "true" || value.Name == "false" {
+			return ast.NewIdent("bool")
 		}
 	}
 	return nil
 }
 
-func analyzeFuncDecl(fset *token.FileSet, d *ast.FuncDecl, exported *Exported) error {
+// isDeprecated reports whether the first available doc comment, such as that
+// of a spec followed by that of its group, has a "Deprecated:" paragraph.
+func isDeprecated(docs ...*ast.CommentGroup) bool {
+	for _, doc := range docs {
+		if doc == nil {
+			continue
+		}
+
+		for _, paragraph := range strings.Split(doc.Text(), "\n\n") {
+			if strings.HasPrefix(paragraph, "Deprecated: ") {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+func analyzeFuncDecl(d *ast.FuncDecl, interfaces interfaceSet, options analyzeOptions, exported *Exported) error {
 	if !d.Name.IsExported() {
+		slog.Debug("skipping function", "name", d.Name.Name, "reason", "unexported")
 		return nil
 	}
 
-	formatted, err := formatNode(fset, d.Type)
+	name := d.Name.Name
+	funcType := stripParamNames(d.Type)
+	if options.strictOrder {
+		funcType.Params = keepParamNames(d.Type.Params)
+	}
+	funcType.TypeParams = expandConstraints(funcType.TypeParams, interfaces)
+	kind, symbols := "function", exported.Functions
+	if d.Recv != nil {
+		// Methods are only reachable through their exported receiver types
+		receiver := receiverTypeName(d.Recv)
+		if !ast.IsExported(receiver) {
+			slog.Debug("skipping method", "name", receiver+"."+name, "reason", "unexported receiver type")
+			return nil
+		}
+
+		name = receiver + "." + name
+		funcType = methodExprType(receiverExpr(d.Recv), funcType)
+		kind, symbols = "method", exported.Methods
+	}
+
+	formatted, err := formatNode(funcType)
 	if err != nil {
 		slog.Warn("failed to format function", "name", d.Name.Name, "error", err)
 		return nil
 	}
+	symbols[name] = formatted
+	slog.Debug("recording "+kind, "name", name)
 
-	exported.Functions[d.Name.Name] = formatted
+	if isDeprecated(d.Doc) {
+		exported.Deprecated[name] = true
+	}
 	return nil
 }
 
-func simplifyType(typeNode ast.Expr) ast.Node {
+// receiverTypeName returns the name of the type a method is declared on,
+// without any pointer or type parameters.
+func receiverTypeName(recv *ast.FieldList) string {
+	if len(recv.List) == 0 {
+		return ""
+	}
+	return baseTypeName(recv.List[0].Type)
+}
+
+// baseTypeName returns the name of a type of the package, without any pointer
+// or type arguments, or "" for a type of another package.
+func baseTypeName(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// receiverExpr returns the receiver type of a method without its type
+// parameters, such as "*T" for "func (t *T[E]) M()".
+func receiverExpr(recv *ast.FieldList) ast.Expr {
+	var receiver ast.Expr = ast.NewIdent(receiverTypeName(recv))
+	if len(recv.List) > 0 && isPointerReceiver(recv.List[0].Type) {
+		receiver = &ast.StarExpr{X: receiver}
+	}
+	return receiver
+}
+
+// methodExprType returns the type of the method expression, such as
+// "func(*T, int)" for "func (t *T) M(int)", so that the stored signature of a
+// method records whether its receiver is a pointer.
+func methodExprType(receiver ast.Expr, funcType *ast.FuncType) *ast.FuncType {
+	receiverField := &ast.Field{Type: receiver}
+	// Parameters are either all named or all unnamed
+	if funcType.Params != nil && len(funcType.Params.List) > 0 && len(funcType.Params.List[0].Names) > 0 {
+		receiverField.Names = []*ast.Ident{ast.NewIdent("_")}
+	}
+	params := &ast.FieldList{List: []*ast.Field{receiverField}}
+	if funcType.Params != nil {
+		params.List = append(params.List, funcType.Params.List...)
+	}
+
+	return &ast.FuncType{
+		Func:       funcType.Func,
+		TypeParams: funcType.TypeParams,
+		Params:     params,
+		Results:    funcType.Results,
+	}
+}
+
+func isPointerReceiver(expr ast.Expr) bool {
+	for {
+		switch e := expr.(type) {
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.StarExpr:
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+func simplifyType(typeNode ast.Expr, interfaces interfaceSet, options analyzeOptions) ast.Node {
+	if interfaceType, ok := typeNode.(*ast.InterfaceType); ok {
+		return stripMethodNames(interfaces.expand(interfaceType), options)
+	}
+
 	structType, ok := typeNode.(*ast.StructType)
 	if !ok {
-		return typeNode
+		return stripFuncNames(typeNode)
 	}
 
 	// Only include exported fields in struct types
 	var exportedFields []*ast.Field
 	for _, field := range structType.Fields.List {
 		if len(field.Names) > 0 && field.Names[0].IsExported() {
-			exportedFields = append(exportedFields, field)
+			// Tags are not part of the type, so only keep them when asked to
+			tag := field.Tag
+			if !options.trackTags {
+				tag = nil
+			}
+			exportedFields = append(exportedFields, &ast.Field{Names: field.Names, Type: stripFuncNames(field.Type), Tag: tag})
 		}
 	}
 
@@ -230,77 +1925,194 @@ func simplifyType(typeNode ast.Expr) ast.Node {
 	}
 }
 
-func formatNode(fset *token.FileSet, node ast.Node) (string, error) {
+// stripParamNames returns a copy of the function type without parameter and
+// result names, as they are not part of a function's type identity.
+func stripParamNames(funcType *ast.FuncType) *ast.FuncType {
+	return &ast.FuncType{
+		Func:       funcType.Func,
+		TypeParams: funcType.TypeParams,
+		Params:     stripFieldNames(funcType.Params),
+		Results:    stripFieldNames(funcType.Results),
+	}
+}
+
+// keepParamNames is stripFieldNames for -strict-order, which keeps the name
+// of every parameter, but not those within their types.
+func keepParamNames(fields *ast.FieldList) *ast.FieldList {
+	if fields == nil {
+		return nil
+	}
+
+	var list []*ast.Field
+	for _, field := range fields.List {
+		if len(field.Names) == 0 {
+			list = append(list, &ast.Field{Type: stripFuncNames(field.Type)})
+		}
+		for _, name := range field.Names {
+			list = append(list, &ast.Field{Names: []*ast.Ident{ast.NewIdent(name.Name)}, Type: stripFuncNames(field.Type)})
+		}
+	}
+
+	return &ast.FieldList{
+		Opening: fields.Opening,
+		List:    list,
+		Closing: fields.Closing,
+	}
+}
+
+// stripFieldNames expands grouped names (a, b int) into one unnamed field per
+// name so that positional types are kept.
+func stripFieldNames(fields *ast.FieldList) *ast.FieldList {
+	if fields == nil {
+		return nil
+	}
+
+	var list []*ast.Field
+	for _, field := range fields.List {
+		count := max(len(field.Names), 1)
+		for range count {
+			list = append(list, &ast.Field{Type: stripFuncNames(field.Type)})
+		}
+	}
+
+	return &ast.FieldList{
+		Opening: fields.Opening,
+		List:    list,
+		Closing: fields.Closing,
+	}
+}
+
+// stripFuncNames returns a copy of the type with the parameter and result
+// names removed from every func type it is built from, such as the callback
+// in "[]func(event Event)" or "struct{ OnEvent func(event Event) }".
+func stripFuncNames(typ ast.Expr) ast.Expr {
+	switch t := typ.(type) {
+	case *ast.FuncType:
+		return stripParamNames(t)
+	case *ast.StarExpr:
+		return &ast.StarExpr{Star: t.Star, X: stripFuncNames(t.X)}
+	case *ast.ParenExpr:
+		return &ast.ParenExpr{Lparen: t.Lparen, X: stripFuncNames(t.X), Rparen: t.Rparen}
+	case *ast.ArrayType:
+		return &ast.ArrayType{Lbrack: t.Lbrack, Len: t.Len, Elt: stripFuncNames(t.Elt)}
+	case *ast.MapType:
+		return &ast.MapType{Map: t.Map, Key: stripFuncNames(t.Key), Value: stripFuncNames(t.Value)}
+	case *ast.ChanType:
+		return &ast.ChanType{Begin: t.Begin, Arrow: t.Arrow, Dir: t.Dir, Value: stripFuncNames(t.Value)}
+	case *ast.Ellipsis:
+		return &ast.Ellipsis{Ellipsis: t.Ellipsis, Elt: stripFuncNames(t.Elt)}
+	case *ast.IndexExpr:
+		return &ast.IndexExpr{X: t.X, Lbrack: t.Lbrack, Index: stripFuncNames(t.Index), Rbrack: t.Rbrack}
+	case *ast.IndexListExpr:
+		indices := make([]ast.Expr, 0, len(t.Indices))
+		for _, index := range t.Indices {
+			indices = append(indices, stripFuncNames(index))
+		}
+		return &ast.IndexListExpr{X: t.X, Lbrack: t.Lbrack, Indices: indices, Rbrack: t.Rbrack}
+	case *ast.StructType:
+		return &ast.StructType{Struct: t.Struct, Fields: stripMemberNames(t.Fields), Incomplete: t.Incomplete}
+	case *ast.InterfaceType:
+		return &ast.InterfaceType{Interface: t.Interface, Methods: stripMemberNames(t.Methods), Incomplete: t.Incomplete}
+	}
+	return typ
+}
+
+// stripMemberNames strips the func types of the fields of a struct, or the
+// methods of an interface, keeping the names of the fields and methods.
+func stripMemberNames(fields *ast.FieldList) *ast.FieldList {
+	if fields == nil {
+		return nil
+	}
+
+	list := make([]*ast.Field, 0, len(fields.List))
+	for _, field := range fields.List {
+		list = append(list, &ast.Field{Names: field.Names, Type: stripFuncNames(field.Type), Tag: field.Tag})
+	}
+	return &ast.FieldList{Opening: fields.Opening, List: list, Closing: fields.Closing}
+}
+
+// stripMethodNames strips the parameter and result names of the methods of
+// an interface, keeping the parameter names for -strict-order as it does for
+// functions.
+func stripMethodNames(interfaceType *ast.InterfaceType, options analyzeOptions) *ast.InterfaceType {
+	stripped := stripFuncNames(interfaceType).(*ast.InterfaceType)
+	if !options.strictOrder {
+		return stripped
+	}
+
+	for i, field := range interfaceType.Methods.List {
+		if funcType, ok := field.Type.(*ast.FuncType); ok && len(field.Names) > 0 {
+			stripped.Methods.List[i].Type.(*ast.FuncType).Params = keepParamNames(funcType.Params)
+		}
+	}
+	return stripped
+}
+
+// formatNode prints node without the positions it was parsed with, so that
+// line breaks in the source do not change the stored signature.
+func formatNode(node ast.Node) (string, error) {
 	var buf bytes.Buffer
-	if err := format.Node(&buf, fset, node); err != nil {
+	if err := format.Node(&buf, token.NewFileSet(), node); err != nil {
 		return "", err
 	}
 	return buf.String(), nil
 }
 
-func calculateVersion(previousState State, currentExported Exported) Version {
+func calculateVersion(previousState State, changes []Change, minBump Bump) Version {
 	previousVersion := parseVersion(previousState.Version)
 
-	hasBreaking := hasBreakingChanges(previousState.Exported, currentExported)
-	hasFeatures := hasNewFeatures(previousState.Exported, currentExported)
-
-	if hasBreaking {
+	switch max(maxBump(changes), minBump) {
+	case Major:
 		return Version{Major: previousVersion.Major + 1, Minor: 0, Patch: 0}
-	}
-	if hasFeatures {
+	case Minor:
 		return Version{Major: previousVersion.Major, Minor: previousVersion.Minor + 1, Patch: 0}
 	}
 	return Version{Major: previousVersion.Major, Minor: previousVersion.Minor, Patch: previousVersion.Patch + 1}
 }
 
+// versionBump returns the kind of bump that leads from previous to next
+func versionBump(previous, next Version) Bump {
+	switch {
+	case next.Major != previous.Major:
+		return Major
+	case next.Minor != previous.Minor:
+		return Minor
+	}
+	return Patch
+}
+
+// parseVersion parses a version such as 1.2.3, with an optional v prefix,
+// falling back to 0.0.0 when it is invalid
 func parseVersion(version string) Version {
-	var v Version
-	n, err := fmt.Sscanf(version, "%d.%d.%d", &v.Major, &v.Minor, &v.Patch)
-	if err != nil || n != 3 {
+	v, err := readVersion(version)
+	if err != nil {
 		slog.Warn("failed to parse version, using default", "version", version, "error", err)
 		return Version{Major: 0, Minor: 0, Patch: 0}
 	}
 	return v
 }
 
-func (v Version) String() string {
-	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
-}
+// readVersion parses a version such as v1.2.3+build
+func readVersion(version string) (Version, error) {
+	core, build, _ := strings.Cut(strings.TrimPrefix(version, "v"), "+")
 
-func hasBreakingChanges(previous, current Exported) bool {
-	// Check for removed or changed types
-	for name, previousType := range previous.Types {
-		currentType, exists := current.Types[name]
-		if !exists || currentType != previousType {
-			return true
-		}
+	var v Version
+	n, err := fmt.Sscanf(core, "%d.%d.%d", &v.Major, &v.Minor, &v.Patch)
+	if err != nil || n != 3 {
+		return Version{}, fmt.Errorf("invalid version %q: %w", version, err)
 	}
+	v.Build = build
 
-	// Check for removed or changed functions
-	for name, previousFunc := range previous.Functions {
-		currentFunc, exists := current.Functions[name]
-		if !exists || currentFunc != previousFunc {
-			return true
-		}
+	if v.String() != strings.TrimPrefix(version, "v") {
+		return Version{}, fmt.Errorf("invalid version %q", version)
 	}
-
-	return false
+	return v, nil
 }
 
-func hasNewFeatures(previous, current Exported) bool {
-	// Check for new types
-	for name := range current.Types {
-		if _, exists := previous.Types[name]; !exists {
-			return true
-		}
-	}
-
-	// Check for new functions
-	for name := range current.Functions {
-		if _, exists := previous.Functions[name]; !exists {
-			return true
-		}
+func (v Version) String() string {
+	version := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Build != "" {
+		version += "+" + v.Build
 	}
-
-	return false
+	return version
 }
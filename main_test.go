@@ -1,7 +1,12 @@
 package main_test
 
 import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -18,7 +23,9 @@ type testpair struct {
 
 	afterFiles   map[string]string
 	afterVersion string
+	afterOutput  []string
 
+	args []string
 	name string
 }
 
@@ -69,7 +76,7 @@ func TestMain(t *testing.T) {
 			afterVersion: "0.1.1",
 		},
 		{
-			name: "add exported field",
+			name: "add exported field (minor)",
 			beforeFiles: map[string]string{
 				"test.go": "package main\ntype Test struct{}",
 			},
@@ -77,7 +84,32 @@ func TestMain(t *testing.T) {
 			afterFiles: map[string]string{
 				"test.go": "package main\ntype Test struct{Name string}",
 			},
+			afterVersion: "0.2.0",
+			afterOutput:  []string{"changed exported type Test: added field Name"},
+		},
+		{
+			name: "remove exported field (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{Name string; Age int}",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{Name string}",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type Test: removed field Age"},
+		},
+		{
+			name: "add one exported field and change another (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{Name string}",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{Name []byte; Age int}",
+			},
 			afterVersion: "1.0.0",
+			afterOutput:  []string{"field Name type changed from string to []byte; added field Age"},
 		},
 		// complex cases
 		{
@@ -158,72 +190,3019 @@ func TestMain(t *testing.T) {
 			afterVersion: "1.0.0",
 		},
 		{
-			name: "multiple changes: add unexported field + add exported function -> minor",
+			name: "rename function parameter (patch)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nfunc Exported(a int) {}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nfunc Exported(b int) {}\n",
+			},
+			afterVersion: "0.1.1",
+		},
+		{
+			name: "rename grouped function parameters (patch)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nfunc Exported(a, b int) {}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nfunc Exported(x int, y int) {}\n",
+			},
+			afterVersion: "0.1.1",
+		},
+		{
+			name: "name function results (patch)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nfunc Exported() (int, error) { return 0, nil }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nfunc Exported() (n int, err error) { return 0, nil }\n",
+			},
+			afterVersion: "0.1.1",
+		},
+		{
+			name: "change named function result type (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nfunc Exported() (n int) { return 0 }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nfunc Exported() (n int64) { return 0 }\n",
+			},
+			afterVersion: "1.0.0",
+		},
+		{
+			name: "narrow channel direction of function result (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nfunc Exported() chan int { return nil }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nfunc Exported() <-chan int { return nil }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"result 1 channel direction changed from chan int to <-chan int"},
+		},
+		{
+			name: "widen channel direction of function result (minor)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nfunc Exported() <-chan int { return nil }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nfunc Exported() chan int { return nil }\n",
+			},
+			afterVersion: "0.2.0",
+			afterOutput:  []string{"result 1 channel direction changed from <-chan int to chan int"},
+		},
+		{
+			name: "narrow channel direction of function parameter (minor)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nfunc Exported(c chan int) {}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nfunc Exported(c <-chan int) {}\n",
+			},
+			afterVersion: "0.2.0",
+		},
+		{
+			name: "change struct tag without tracking tags (patch)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{Name string `json:\"name\"`}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{Name string `json:\"full_name\"`}\n",
+			},
+			afterVersion: "0.1.1",
+		},
+		{
+			name: "change struct tag while tracking tags (minor)",
+			args: []string{"-track-tags"},
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{Name string `json:\"name\"`}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{Name string `json:\"name,omitempty\" yaml:\"name\"`}\n",
+			},
+			afterVersion: "0.2.0",
+			afterOutput:  []string{"struct tags changed"},
+		},
+		{
+			name: "rename a JSON field while tracking tags (major)",
+			args: []string{"-track-tags"},
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{ID string `json:\"id\"`}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{ID string `json:\"user_id,omitempty\"`}\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{`changed exported type Test: JSON name of field ID changed from \"id\" to \"user_id\"`},
+		},
+		{
+			name: "leave a field out of JSON while tracking tags (major)",
+			args: []string{"-track-tags"},
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{ID string `json:\"id\"`}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{ID string `json:\"-\"`}\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type Test: field ID is no longer encoded to JSON"},
+		},
+		{
+			name: "tag a field with its default JSON name while tracking tags (minor)",
+			args: []string{"-track-tags"},
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{ID string}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{ID string `json:\"ID\"`}\n",
+			},
+			afterVersion: "0.2.0",
+			afterOutput:  []string{"struct tags changed for field ID"},
+		},
+		{
+			name: "change field type while tracking tags (major)",
+			args: []string{"-track-tags"},
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{Name string `json:\"name\"`}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{Name int `json:\"name\"`}\n",
+			},
+			afterVersion: "1.0.0",
+		},
+		{
+			name: "hashed signatures: no changes (patch)",
+			args: []string{"-full-signatures=false"},
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{Name string}\nfunc Exported(a int) {}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{Name string}\nfunc Exported(b int) {}\n",
+			},
+			afterVersion: "0.1.1",
+		},
+		{
+			name: "hashed signatures: add exported function (minor)",
+			args: []string{"-full-signatures=false"},
+			beforeFiles: map[string]string{
+				"test.go": "package main\nfunc Exported() {}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nfunc Exported() {}\nfunc Another() {}\n",
+			},
+			afterVersion: "0.2.0",
+		},
+		{
+			name: "hashed signatures: change exported function signature (major)",
+			args: []string{"-full-signatures=false"},
+			beforeFiles: map[string]string{
+				"test.go": "package main\nfunc Exported(a int) {}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nfunc Exported(a string) {}\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported function Exported: signature changed"},
+		},
+		{
+			name: "hashed signatures: remove exported type (major)",
+			args: []string{"-full-signatures=false"},
 			beforeFiles: map[string]string{
 				"test.go": "package main\ntype Test struct{}\n",
 			},
 			beforeVersion: "0.1.0",
 			afterFiles: map[string]string{
-				"test.go": "package main\ntype Test struct{age int}\nfunc Exported() {}\n",
+				"test.go": "package main\n",
+			},
+			afterVersion: "1.0.0",
+		},
+		{
+			name: "add String method to exported type (minor)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{}\nfunc (t Test) String() string { return \"\" }\n",
 			},
 			afterVersion: "0.2.0",
+			afterOutput:  []string{"added exported method Test.String"},
 		},
-	}
-
-	path, err := gexec.Build("github.com/jtarchie/semtype")
-	assert.Expect(err).NotTo(HaveOccurred())
-	defer gexec.CleanupBuildArtifacts()
-
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			assert := NewGomegaWithT(t)
-
-			dir, err := os.MkdirTemp("", "")
-			assert.Expect(err).NotTo(HaveOccurred())
-
-			for filename, contents := range test.beforeFiles {
-				fullPath := filepath.Join(dir, filename)
-
-				dir := filepath.Dir(fullPath)
-				err := os.MkdirAll(dir, os.ModePerm)
-				assert.Expect(err).NotTo(HaveOccurred())
-
-				err = os.WriteFile(fullPath, []byte(contents), 0644)
-				assert.Expect(err).NotTo(HaveOccurred())
-			}
-
-			output := gbytes.NewBuffer()
-			session, err := gexec.Start(exec.Command(path, "-dir", dir), output, output)
-			assert.Expect(err).NotTo(HaveOccurred())
-			assert.Eventually(session).Should(gexec.Exit(0), fmt.Sprintf("output: %s", output.Contents()))
-			assert.Expect(output).To(gbytes.Say(test.beforeVersion))
-
-			for filename, contents := range test.afterFiles {
-				fullPath := filepath.Join(dir, filename)
-
-				dir := filepath.Dir(fullPath)
-				err := os.MkdirAll(dir, os.ModePerm)
-				assert.Expect(err).NotTo(HaveOccurred())
-
-				err = os.WriteFile(fullPath, []byte(contents), 0644)
-				assert.Expect(err).NotTo(HaveOccurred())
-			}
-
-			// remove any files that were in beforeFiles but not in afterFiles to simulate removal
-			for filename := range test.beforeFiles {
-				if _, ok := test.afterFiles[filename]; !ok {
-					fullPath := filepath.Join(dir, filename)
-					err := os.Remove(fullPath)
-					assert.Expect(err).NotTo(HaveOccurred())
-				}
-			}
-
-			assert.Expect(output.Clear()).NotTo(HaveOccurred())
-			session, err = gexec.Start(exec.Command(path, "-dir", dir), output, output)
-			assert.Expect(err).NotTo(HaveOccurred())
-			assert.Eventually(session).Should(gexec.Exit(0))
-			assert.Expect(output).To(gbytes.Say(test.afterVersion))
-		})
-
-	}
+		{
+			name: "change String method signature (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{}\nfunc (t Test) String() string { return \"\" }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{}\nfunc (t Test) String(verbose bool) string { return \"\" }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported method Test.String"},
+		},
+		{
+			name: "remove method sharing a name with a function (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{}\nfunc (t Test) Exported() {}\nfunc Exported() {}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{}\nfunc Exported() {}\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"removed exported method Test.Exported"},
+		},
+		{
+			name: "add exported method to unexported type (patch)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype test struct{}\n",
+			},
+			beforeVersion: "0.0.1",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype test struct{}\nfunc (t *test) Exported() {}\n",
+			},
+			afterVersion: "0.0.2",
+		},
+		{
+			name: "no changes with minimum minor bump (minor)",
+			args: []string{"-min-bump", "minor"},
+			beforeFiles: map[string]string{
+				"test.go": "package main\nfunc Exported() {}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nfunc Exported() {}\n",
+			},
+			afterVersion: "0.2.0",
+		},
+		{
+			name: "breaking change with minimum minor bump (major)",
+			args: []string{"-min-bump", "minor"},
+			beforeFiles: map[string]string{
+				"test.go": "package main\nfunc Exported() {}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\n",
+			},
+			afterVersion: "1.0.0",
+		},
+		{
+			name: "no changes with minimum major bump (major)",
+			args: []string{"-min-bump", "major"},
+			beforeFiles: map[string]string{
+				"test.go": "package main\nfunc Exported() {}\n",
+			},
+			beforeVersion: "1.0.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nfunc Exported() {}\n",
+			},
+			afterVersion: "2.0.0",
+		},
+		{
+			name: "add exported constant (minor)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nconst A = 1\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nconst A = 1\nconst B = 2\n",
+			},
+			afterVersion: "0.2.0",
+		},
+		{
+			name: "rewrite exported constant expression with the same value (patch)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nconst unit = 2\nconst A = 4\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nconst unit = 2\nconst A = unit * (1 << 1)\n",
+			},
+			afterVersion: "0.1.1",
+		},
+		{
+			name: "remove the first constant of an iota block (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Level int\nconst (\n\tDebug Level = iota\n\tInfo\n\tWarn\n)\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Level int\nconst (\n\tInfo Level = iota\n\tWarn\n)\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput: []string{
+				"removed exported constant Debug",
+				"changed exported constant Info: value changed from 1 to 0",
+				"changed exported constant Warn: value changed from 2 to 1",
+			},
+		},
+		{
+			name: "reorder iota constants (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Color int\nconst (\n\tRed Color = iota\n\tGreen\n)\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Color int\nconst (\n\tGreen Color = iota\n\tRed\n)\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput: []string{
+				"changed exported constant Green: value changed from 1 to 0",
+				"changed exported constant Red: value changed from 0 to 1",
+			},
+		},
+		{
+			name: "change the argument of a builtin call in a constant (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nconst Size = len(\"abc\")\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nconst Size = len(\"abcd\")\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{`changed exported constant Size: value changed from len(\"abc\") to len(\"abcd\")`},
+		},
+		{
+			name: "change the operand of a conversion in a constant (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nimport \"time\"\nconst Timeout = time.Duration(1)\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nimport \"time\"\nconst Timeout = time.Duration(2)\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported constant Timeout: value changed from 1 to 2"},
+		},
+		{
+			name: "change named type to another named type (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype ID string\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype ID int\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type ID: underlying type changed: string → int"},
+		},
+		{
+			name: "change kind of named type to struct (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype ID string\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype ID struct{}\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type ID: kind changed from named to struct"},
+		},
+		{
+			name: "change kind of func type to interface (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Handler func()\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Handler interface{ Handle() }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type Handler: kind changed from func to interface"},
+		},
+		{
+			name: "remove one type from a grouped declaration (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype (\n\tA int\n\tB string\n)\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype (\n\tA int\n)\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"removed exported type B"},
+		},
+		{
+			name: "deprecate one type in a grouped declaration (minor)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype (\n\tA int\n\tB string\n)\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype (\n\tA int\n\t// Deprecated: use A.\n\tB string\n)\n",
+			},
+			afterVersion: "0.2.0",
+			afterOutput:  []string{"deprecated exported type B"},
+		},
+		{
+			name: "deprecate a grouped constant declaration (minor)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nconst (\n\tA = 1\n\tB = 2\n)\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\n// Deprecated: use C.\nconst (\n\tA = 1\n\tB = 2\n)\nconst C = 3\n",
+			},
+			afterVersion: "0.2.0",
+			afterOutput:  []string{"deprecated exported constant A", "deprecated exported constant B"},
+		},
+		{
+			name: "deprecate an exported function (minor)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nfunc Exported() {}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\n// Exported does nothing.\n//\n// Deprecated: do not use.\nfunc Exported() {}\n",
+			},
+			afterVersion: "0.2.0",
+			afterOutput:  []string{"deprecated exported function Exported"},
+		},
+		{
+			name: "cgo file contributes exported functions (minor)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\n",
+			},
+			beforeVersion: "0.0.1",
+			afterFiles: map[string]string{
+				"test.go": "package main\n\n// #include <stdio.h>\nimport \"C\"\n\nfunc Exported(n C.int) int { return int(n) }\n",
+			},
+			afterVersion: "0.1.0",
+			afterOutput:  []string{"added exported function Exported"},
+		},
+		{
+			name: "add compiler directive to exported function (patch)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\n\nimport \"C\"\n\nfunc Exported(a int) int { return a }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\n\nimport \"C\"\n\n//go:noinline\n//export Exported\nfunc Exported(a int) int { return a }\n",
+			},
+			afterVersion: "0.1.1",
+		},
+		{
+			name: "change only a parameter type (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nfunc Exported(a int) int { return 0 }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nfunc Exported(a string) int { return 0 }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported function Exported: parameter 1 type changed from int to string\""},
+		},
+		{
+			name: "change only a result type (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nfunc Exported(a int) int { return 0 }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nfunc Exported(a int) string { return \"\" }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported function Exported: result 1 type changed from int to string\""},
+		},
+		{
+			name: "change parameter and result types (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nfunc Exported(a int) int { return 0 }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nfunc Exported(a, b int) string { return \"\" }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"parameters changed from (int) to (int, int); result 1 type changed from int to string"},
+		},
+		{
+			name: "recursive: remove exported type from subpackage (major)",
+			args: []string{"-recursive"},
+			beforeFiles: map[string]string{
+				"test.go":     "package main\n",
+				"sub/test.go": "package sub\ntype Test struct{}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go":     "package main\n",
+				"sub/test.go": "package sub\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"removed exported type sub.Test"},
+		},
+		{
+			name: "recursive: add exported type to internal package (patch)",
+			args: []string{"-recursive"},
+			beforeFiles: map[string]string{
+				"test.go":           "package main\n",
+				"internal/test.go":  "package internal\n",
+				"sub/internal/a.go": "package internal\ntype A struct{}\n",
+			},
+			beforeVersion: "0.0.1",
+			afterFiles: map[string]string{
+				"test.go":           "package main\n",
+				"internal/test.go":  "package internal\ntype Test struct{}\n",
+				"sub/internal/a.go": "package internal\n",
+			},
+			afterVersion: "0.0.2",
+		},
+		{
+			name: "recursive: add exported type to included internal package (minor)",
+			args: []string{"-recursive", "-include-internal"},
+			beforeFiles: map[string]string{
+				"test.go":          "package main\n",
+				"internal/test.go": "package internal\n",
+			},
+			beforeVersion: "0.0.1",
+			afterFiles: map[string]string{
+				"test.go":          "package main\n",
+				"internal/test.go": "package internal\ntype Test struct{}\n",
+			},
+			afterVersion: "0.1.0",
+			afterOutput:  []string{"added exported type internal.Test"},
+		},
+		{
+			name: "alias an import used by an exported signature (patch)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nimport \"net/http\"\nfunc Exported(h http.Handler) {}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nimport (\n\tstdhttp \"net/http\"\n)\n\nfunc Exported(\n\th stdhttp.Handler,\n) {\n}\n",
+			},
+			afterVersion: "0.1.1",
+		},
+		{
+			name: "alias an import used by an exported type (patch)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nimport \"io\"\ntype Source struct{ Reader io.Reader }\nfunc Open(f io.Reader) Source { return Source{} }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nimport stdio \"io\"\ntype Source struct{ Reader stdio.Reader }\nfunc Open(f stdio.Reader) Source { return Source{} }\n",
+			},
+			afterVersion: "0.1.1",
+		},
+		{
+			name: "alias an import with the name it declares (patch)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nimport \"gopkg.in/yaml.v3\"\nfunc Decode(node *yaml.Node) {}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nimport yaml \"gopkg.in/yaml.v3\"\nfunc Decode(node *yaml.Node) {}\n",
+			},
+			afterVersion: "0.1.1",
+		},
+		{
+			name: "alias an import of a major version (patch)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nimport \"math/rand/v2\"\nfunc Seed(source rand.Source) {}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nimport r \"math/rand/v2\"\nfunc Seed(source r.Source) {}\n",
+			},
+			afterVersion: "0.1.1",
+		},
+		{
+			name: "reformat an exported struct and method (patch)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{ Name string; Age int }\nfunc (t Test) Greet(greeting string, count int) string { return \"\" }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\n\ntype Test struct {\n\tName string\n\n\tAge int\n}\n\nfunc (t Test) Greet(\n\tgreeting string,\n\tcount int,\n) string {\n\treturn \"\"\n}\n",
+			},
+			afterVersion: "0.1.1",
+		},
+		{
+			name: "add method to an embedded unexported interface (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype reader interface{ Read() }\ntype ReadCloser interface{ reader; Close() }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype reader interface{ Read(); ReadAll() }\ntype ReadCloser interface{ reader; Close() }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type ReadCloser"},
+		},
+		{
+			name: "add method to an embedded exported interface (major)",
+			beforeFiles: map[string]string{
+				"reader.go": "package main\ntype Reader interface{ Read() }\n",
+				"test.go":   "package main\ntype ReadWriter interface{ Reader; Write() }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"reader.go": "package main\ntype Reader interface{ Read(); ReadAll() }\n",
+				"test.go":   "package main\ntype ReadWriter interface{ Reader; Write() }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type Reader", "changed exported type ReadWriter"},
+		},
+		{
+			name: "inline the methods of an embedded interface (patch)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype reader interface{ Read() }\ntype ReadCloser interface{ reader; Close() }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype ReadCloser interface{ Read(); Close() }\n",
+			},
+			afterVersion: "0.1.1",
+		},
+		{
+			name: "remove a nested package (major)",
+			args: []string{"-recursive"},
+			beforeFiles: map[string]string{
+				"test.go":      "package main\nfunc Exported() {}\n",
+				"sub/sub.go":   "package sub\ntype Test struct{}\nfunc New() Test { return Test{} }\n",
+				"sub/extra.go": "package sub\nconst Size = 1\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nfunc Exported() {}\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{`"reason":"removed package sub"`},
+		},
+		{
+			name: "rename a parameter of a callback field (patch)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Event struct{}\ntype Handler struct{ OnEvent func(event Event) error }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Event struct{}\ntype Handler struct{ OnEvent func(e Event) (err error) }\n",
+			},
+			afterVersion: "0.1.1",
+		},
+		{
+			name: "change a parameter type of a callback field (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Event struct{}\ntype Handler struct{ OnEvent func(event Event) }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Event struct{}\ntype Handler struct{ OnEvent func(event *Event) }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"field OnEvent type changed: added pointer indirection"},
+		},
+		{
+			name: "change a parameter of a callback field to another type (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Event struct{}\ntype Handler struct{ OnEvent func(event Event) }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Event struct{}\ntype Handler struct{ OnEvent func(event string) }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"field OnEvent type changed from func(Event) to func(string)"},
+		},
+		{
+			name: "rename a parameter of a callback in an anonymous struct field (patch)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Handler struct{ Hooks struct{ OnEvent func(event string) } }\nvar Default struct{ OnEvent func(event string) }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Handler struct{ Hooks struct{ OnEvent func(name string) } }\nvar Default struct{ OnEvent func(name string) }\n",
+			},
+			afterVersion: "0.1.1",
+		},
+		{
+			name: "rename a parameter of a method of an anonymous interface field (patch)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Handler struct{ Logger interface{ Log(message string) } }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Handler struct{ Logger interface{ Log(text string) } }\n",
+			},
+			afterVersion: "0.1.1",
+		},
+		{
+			name: "rename a parameter of a method of an exported interface (patch)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Doer interface{ Do(a int) (err error) }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Doer interface{ Do(b int) error }\n",
+			},
+			afterVersion: "0.1.1",
+		},
+		{
+			name: "rename a parameter of a callback in a type argument (patch)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype List[T any] []T\ntype Pair[K, V any] struct{}\ntype Handlers struct{ All List[func(event string)]; ByName Pair[string, func(event string)] }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype List[T any] []T\ntype Pair[K, V any] struct{}\ntype Handlers struct{ All List[func(name string)]; ByName Pair[string, func(name string)] }\n",
+			},
+			afterVersion: "0.1.1",
+		},
+		{
+			name: "change a parameter type of a callback in an anonymous struct field (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Handler struct{ Hooks struct{ OnEvent func(event string) } }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Handler struct{ Hooks struct{ OnEvent func(event int) } }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"field Hooks type changed from struct{OnEvent func(string)} to struct{OnEvent func(int)}"},
+		},
+		{
+			name: "rename a parameter of a named func type (patch)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Callback func(name string, values ...int)\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Callback func(key string, args ...int)\n",
+			},
+			afterVersion: "0.1.1",
+		},
+		{
+			name: "add a variadic parameter (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Option func()\nfunc F() {}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Option func()\nfunc F(opts ...Option) {}\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{`"reason":"changed exported function F: signature changed: added variadic parameter"`},
+		},
+		{
+			name: "change a value receiver to a pointer receiver (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{}\nfunc (t Test) String() string { return \"\" }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{}\nfunc (t *Test) String() string { return \"\" }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported method Test.String: receiver changed from Test to *Test"},
+		},
+		{
+			name: "change a pointer receiver to a value receiver (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{}\nfunc (t *Test) String() string { return \"\" }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{}\nfunc (Test) String() string { return \"\" }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported method Test.String: receiver changed from *Test to Test"},
+		},
+		{
+			name: "rename a method receiver (patch)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{}\nfunc (t *Test) String() string { return \"\" }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{}\nfunc (test *Test) String() string { return \"\" }\n",
+			},
+			afterVersion: "0.1.1",
+		},
+		{
+			name: "change the key type of a named map (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Handler func()\ntype Registry map[string]Handler\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Handler func()\ntype Registry map[int]Handler\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type Registry: map key type changed from string to int"},
+		},
+		{
+			name: "change the value type of a named map (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Handler func()\ntype Registry map[string]Handler\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Handler func()\ntype Registry map[string]*Handler\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type Registry: map value type changed from Handler to *Handler"},
+		},
+		{
+			name: "remove an embedded type with a promoted method (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Logger struct{}\nfunc (l *Logger) Log(message string) {}\ntype Server struct{ *Logger }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Logger struct{}\nfunc (l *Logger) Log(message string) {}\ntype Server struct{}\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"removed exported method Server.Log"},
+		},
+		{
+			name: "remove a method of an unexported embedded type (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype base struct{}\nfunc (base) ID() int { return 0 }\nfunc (base) Name() string { return \"\" }\ntype Model struct{ base }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype base struct{}\nfunc (base) ID() int { return 0 }\ntype Model struct{ base }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"removed exported method Model.Name"},
+		},
+		{
+			name: "remove an unexported embedded type with promoted methods (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype base struct{}\nfunc (base) ID() int { return 0 }\nfunc (*base) Close() error { return nil }\ntype Model struct {\n\tbase\n\tName string\n}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype base struct{}\nfunc (base) ID() int { return 0 }\nfunc (*base) Close() error { return nil }\ntype Model struct{ Name string }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"removed exported method Model.ID", "removed exported method Model.Close"},
+		},
+		{
+			name: "declare a method that was promoted (patch)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype base struct{}\nfunc (base) ID() int { return 0 }\ntype Model struct{ base }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Model struct{}\nfunc (Model) ID() int { return 0 }\n",
+			},
+			afterVersion: "0.1.1",
+		},
+		{
+			name: "add a field that shadows a promoted field (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Metadata struct{ Name string }\ntype Resource struct{ Metadata }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Metadata struct{ Name string }\ntype Resource struct {\n\tMetadata\n\tName string\n}\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type Resource: added field Name shadows promoted field Resource.Name"},
+		},
+		{
+			name: "change the type of a promoted field (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype base struct{ ID int }\ntype Model struct{ base }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype base struct{ ID string }\ntype Model struct{ base }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported promoted field Model.ID: type changed from int to string"},
+		},
+		{
+			name: "remove a method that an interface needs (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Store interface {\n\tGet(key string) string\n\tPut(key, value string)\n}\ntype MemStore struct{}\nfunc (m *MemStore) Get(key string) string { return \"\" }\nfunc (m *MemStore) Put(key, value string) {}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Store interface {\n\tGet(key string) string\n\tPut(key, value string)\n}\ntype MemStore struct{}\nfunc (m *MemStore) Get(key string) string { return \"\" }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type MemStore: no longer implements Store"},
+		},
+		{
+			name: "add a type parameter to a generic type (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Cache[K comparable, V any] struct{ items map[K]V }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Option interface{ Apply() }\ntype Cache[K comparable, V any, O Option] struct{ items map[K]V }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type Cache: number of type parameters changed from 2 to 3"},
+		},
+		{
+			name: "remove a type parameter from a generic type (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Cache[K comparable, V any] struct{ items map[K]V }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Cache[V any] struct{ items map[string]V }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type Cache: number of type parameters changed from 2 to 1: [K comparable, V any] to [V any]"},
+		},
+		{
+			name: "relax the constraint of a type parameter of a generic type (minor)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Set[T comparable] struct{ items []T }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Set[T any] struct{ items []T }\n",
+			},
+			afterVersion: "0.2.0",
+			afterOutput:  []string{"changed exported type Set: type parameter T constraint relaxed from comparable to any"},
+		},
+		{
+			name: "tighten the constraint of a type parameter of a generic type (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Set[T any] struct{ items []T }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Set[T comparable] struct{ items []T }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type Set: type parameter T constraint tightened from any to comparable"},
+		},
+		{
+			name: "add a term to the union constraint of a generic type (minor)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Number[T ~int] struct{ value T }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Number[T ~int | ~float64] struct{ value T }\n",
+			},
+			afterVersion: "0.2.0",
+			afterOutput:  []string{"changed exported type Number: type parameter T constraint relaxed from ~int to ~int | ~float64"},
+		},
+		{
+			name: "narrow the union constraint of a generic type to comparable (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Key[T comparable] struct{ value T }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Key[T int | string] struct{ value T }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type Key: type parameter T constraint tightened from comparable to int | string"},
+		},
+		{
+			name: "replace a named constraint with its inline equivalent (patch)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Number interface{ ~int | ~float64 }\nfunc Sum[T Number](values ...T) T { return values[0] }\ntype Vector[T Number] []T\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Number interface{ ~int | ~float64 }\nfunc Sum[T interface{ ~int | ~float64 }](values ...T) T { return values[0] }\ntype Vector[T interface{ ~int | ~float64 }] []T\n",
+			},
+			afterVersion: "0.1.1",
+		},
+		{
+			name: "reorder the terms of a constraint (patch)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Number interface{ ~int | ~float64 }\ntype Vector[T Number] []T\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Number interface{ ~int | ~float64 }\ntype Vector[T interface{ ~float64 | ~int }] []T\n",
+			},
+			afterVersion: "0.1.1",
+			afterOutput:  []string{"changed exported type Vector: type parameter T constraint rewritten from interface{~int | ~float64} to interface{~float64 | ~int}"},
+		},
+		{
+			name: "rename a struct without an alias (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Config struct {\n\tHost string\n\tPort int\n}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Settings struct {\n\tHost string\n\tPort int\n}\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"renamed exported type Config → Settings"},
+		},
+		{
+			name: "add a result to a function (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nfunc Parse(input string) int { return 0 }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nfunc Parse(input string) (int, error) { return 0, nil }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported function Parse: result count changed from 1 to 2: (int) to (int, error); now returns error"},
+		},
+		{
+			name: "remove a result from a method (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Conn struct{}\nfunc (c *Conn) Close() error { return nil }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Conn struct{}\nfunc (c *Conn) Close() {}\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported method Conn.Close: result count changed from 1 to 0: (error) to (); no longer returns error"},
+		},
+		{
+			name: "add an unexported method to an interface (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Store interface {\n\tGet(key string) string\n}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Store interface {\n\tGet(key string) string\n\tsealed()\n}\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type Store: added unexported method sealed, so it can no longer be implemented outside the package"},
+		},
+		{
+			name: "reorder the methods of an interface (patch)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Store interface {\n\tGet(key string) string\n\tPut(key, value string)\n}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Store interface {\n\tPut(key, value string)\n\tGet(key string) string\n}\n",
+			},
+			afterVersion: "0.1.1",
+		},
+		{
+			name: "swap two parameters of the same type with -strict-order (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nfunc Copy(dst, src string) {}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nfunc Copy(src, dst string) {}\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported function Copy: parameters reordered from (dst, src) to (src, dst)"},
+			args:         []string{"-strict-order"},
+		},
+		{
+			name: "rename a parameter of a method with -strict-order (patch)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype File struct{}\nfunc (f *File) Write(data []byte, offset int) {}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype File struct{}\nfunc (f *File) Write(buf []byte, offset int) {}\n",
+			},
+			afterVersion: "0.1.1",
+			afterOutput:  []string{"changed exported method File.Write: parameters renamed from (data, offset) to (buf, offset)"},
+			args:         []string{"-strict-order"},
+		},
+		{
+			name: "swap the parameters of an interface method with -strict-order (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Copier interface{ Copy(dst, src string) }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Copier interface{ Copy(src, dst string) }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type Copier: method Copy parameters reordered from (dst, src) to (src, dst)"},
+			args:         []string{"-strict-order"},
+		},
+		{
+			name: "reorder the methods of an interface (patch)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Store interface{ Get(key string) string; Set(key, value string) }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Store interface{ Set(key, value string); Get(key string) string }\n",
+			},
+			afterVersion: "0.1.1",
+			afterOutput:  []string{"changed exported type Store: methods reordered"},
+		},
+		{
+			name: "pass a parameter by pointer (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Config struct{ Name string }\nfunc Run(name string, config Config) {}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Config struct{ Name string }\nfunc Run(name string, cfg *Config) {}\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported function Run: parameter 2 changed: value → pointer"},
+		},
+		{
+			name: "pass a parameter by value (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Config struct{ Name string }\nfunc Run(config *Config) {}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Config struct{ Name string }\nfunc Run(config Config) {}\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported function Run: parameter 1 changed: pointer → value"},
+		},
+		{
+			name: "return a pointer instead of a value (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Config struct{ Name string }\nfunc Load() (Config, error) { return Config{}, nil }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Config struct{ Name string }\nfunc Load() (*Config, error) { return nil, nil }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported function Load: result 1 changed: value → pointer"},
+		},
+		{
+			name: "return a value instead of a pointer (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Config struct{ Name string }\nfunc Load() *Config { return nil }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Config struct{ Name string }\nfunc Load() Config { return Config{} }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported function Load: result 1 changed: pointer → value"},
+		},
+		{
+			name: "change the type of a variable (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nimport \"net/http\"\nvar DefaultClient *http.Client\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nimport \"net/http\"\nvar DefaultClient http.Client\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported variable DefaultClient: type changed from *http.Client to http.Client"},
+		},
+		{
+			name: "add a variable to a block (minor)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nvar (\n\tMinRetries, MaxRetries int\n)\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nvar (\n\tMinRetries, MaxRetries int\n\tVerbose = false\n)\n",
+			},
+			afterVersion: "0.2.0",
+			afterOutput:  []string{"added exported variable Verbose"},
+		},
+		{
+			name: "remove one of the variables sharing a type (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nvar MinRetries, MaxRetries int\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nvar MaxRetries int\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"removed exported variable MinRetries"},
+		},
+		{
+			name: "change the value of a variable (patch)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Options struct{ Retries int }\nvar Defaults = &Options{Retries: 1}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Options struct{ Retries int }\nvar Defaults = &Options{Retries: 3}\n",
+			},
+			afterVersion: "0.1.1",
+		},
+		{
+			name: "rename a type along with its methods (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Config struct{ Path string }\nfunc (c *Config) Load() error { return nil }\nfunc (c Config) Clone() Config { return c }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Settings struct{ Path string }\nfunc (s *Settings) Load() error { return nil }\nfunc (s Settings) Clone() Settings { return s }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"renamed exported type Config → Settings, along with its methods Clone, Load"},
+		},
+		{
+			name: "stop returning an error from a function (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Config struct{}\nfunc Load(path string) (*Config, error) { return nil, nil }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Config struct{}\nfunc Load(path string) *Config { return nil }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported function Load: result count changed from 2 to 1: (*Config, error) to (*Config); no longer returns error"},
+		},
+		{
+			name: "add a function to a package that does not compile (minor)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nfunc Load() *Missing { return undefined() }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nfunc Load() *Missing { return undefined() }\nfunc Save(value Missing) error { return nil }\n",
+			},
+			afterVersion: "0.2.0",
+			afterOutput:  []string{"added exported function Save"},
+		},
+		{
+			name: "change an undefined parameter type (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nfunc Save(value Missing) {}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nfunc Save(value *Missing) {}\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported function Save: parameter 1 changed: value → pointer"},
+		},
+		{
+			name: "add a method to the inline interface of a field (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Server struct {\n\tHandler interface{ Serve(path string) error }\n}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Server struct {\n\tHandler interface {\n\t\tServe(path string) error\n\t\tClose() error\n\t}\n}\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type Server: field Handler interface changed: added method Close"},
+		},
+		{
+			name: "reorder the methods of the inline interface of a field (patch)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Server struct {\n\tHandler interface {\n\t\tServe(path string) error\n\t\tClose() error\n\t}\n}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Server struct {\n\tHandler interface {\n\t\tClose() error\n\t\tServe(path string) error\n\t}\n}\n",
+			},
+			afterVersion: "0.1.1",
+		},
+		{
+			name: "widen the basic type of a defined type (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Port uint16\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Port uint32\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type Port: underlying type changed: uint16 → uint32"},
+		},
+		{
+			name: "change a defined type from a basic to a named type (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nimport \"time\"\ntype Timeout int64\nvar _ = time.Second\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nimport \"time\"\ntype Timeout time.Duration\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type Timeout: type changed from int64 to time.Duration"},
+		},
+		{
+			name: "implement fmt.Stringer with -track-well-known-interfaces (minor)",
+			args: []string{"-track-well-known-interfaces"},
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Level int\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Level int\nfunc (l Level) String() string { return \"\" }\n",
+			},
+			afterVersion: "0.2.0",
+			afterOutput:  []string{"changed exported type Level: now implements fmt.Stringer"},
+		},
+		{
+			name: "stop implementing error with -track-well-known-interfaces (major)",
+			args: []string{"-track-well-known-interfaces"},
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Failure struct{}\nfunc (f *Failure) Error() string { return \"\" }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Failure struct{}\nfunc (f *Failure) Error() error { return nil }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type Failure: no longer implements error"},
+		},
+		{
+			name: "make a function generic (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nfunc Max(a, b int) int { return a }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Ordered interface{ ~int | ~float64 }\nfunc Max[T Ordered](a, b T) T { return a }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported function Max: became generic with type parameters [T interface{~int | ~float64}]"},
+		},
+		{
+			name: "make a generic function concrete (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nfunc Max[T int | float64](a, b T) T { return a }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nfunc Max(a, b float64) float64 { return a }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported function Max: is no longer generic, dropping type parameters [T int | float64]"},
+		},
+		{
+			name: "give an untyped constant a type (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nconst Timeout = 30\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nimport \"time\"\nconst Timeout time.Duration = 30\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported constant Timeout: became typed as time.Duration"},
+		},
+		{
+			name: "drop the type of a constant (minor)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nconst Retries int = 3\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nconst Retries = 3\n",
+			},
+			afterVersion: "0.2.0",
+			afterOutput:  []string{"changed exported constant Retries: became untyped, was int"},
+		},
+		{
+			name: "drop the type of a constant and change its value (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nconst Retries int = 3\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nconst Retries = 5\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported constant Retries: became untyped, was int; value changed from 3 to 5"},
+		},
+		{
+			name: "change the target of an alias (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nimport \"log\"\ntype Logger = log.Logger\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nimport \"log/slog\"\ntype Logger = slog.Logger\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type Logger: alias target changed: log.Logger → slog.Logger"},
+		},
+		{
+			name: "turn a defined type into an alias of its underlying type (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Level int\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Level = int\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type Level: defined type turned into an alias of int"},
+		},
+		{
+			name: "turn an alias into a defined type (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nimport \"log\"\ntype Logger = log.Logger\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nimport \"log\"\ntype Logger log.Logger\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type Logger: alias of log.Logger turned into a defined type"},
+		},
+		{
+			name: "widen a parameter to an interface of the standard library with -smart-widen (minor)",
+			args: []string{"-smart-widen"},
+			beforeFiles: map[string]string{
+				"test.go": "package main\nimport \"bytes\"\nfunc Parse(r *bytes.Buffer) error { return nil }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nimport \"io\"\nfunc Parse(r io.Reader) error { return nil }\n",
+			},
+			afterVersion: "0.2.0",
+			afterOutput:  []string{"changed exported function Parse: parameter 1 widened from *bytes.Buffer to interface io.Reader"},
+		},
+		{
+			name: "widen a parameter to an interface of the package with -smart-widen (minor)",
+			args: []string{"-smart-widen"},
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Closer interface{ Close() error }\ntype File struct{}\nfunc (f *File) Close() error { return nil }\nfunc Release(f *File) {}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Closer interface{ Close() error }\ntype File struct{}\nfunc (f *File) Close() error { return nil }\nfunc Release(c Closer) {}\n",
+			},
+			afterVersion: "0.2.0",
+			afterOutput:  []string{"changed exported function Release: parameter 1 widened from *File to interface Closer"},
+		},
+		{
+			name: "narrow an interface parameter to a concrete type with -smart-widen (major)",
+			args: []string{"-smart-widen"},
+			beforeFiles: map[string]string{
+				"test.go": "package main\nimport \"io\"\nfunc Parse(r io.Reader) error { return nil }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nimport \"bytes\"\nfunc Parse(r *bytes.Buffer) error { return nil }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported function Parse: parameter 1 type changed from io.Reader to *bytes.Buffer"},
+		},
+		{
+			name: "replace a value parameter with an interface only its pointer satisfies with -smart-widen (major)",
+			args: []string{"-smart-widen"},
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Namer interface{ String() string }\ntype Name struct{}\nfunc (*Name) String() string { return \"\" }\nfunc Print(n Name) {}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Namer interface{ String() string }\ntype Name struct{}\nfunc (*Name) String() string { return \"\" }\nfunc Print(n Namer) {}\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported function Print: parameter 1 type changed from Name to Namer"},
+		},
+		{
+			name: "replace a pointer parameter with an interface of its pointer methods with -smart-widen (minor)",
+			args: []string{"-smart-widen"},
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Namer interface{ String() string }\ntype Name struct{}\nfunc (*Name) String() string { return \"\" }\nfunc Print(n *Name) {}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Namer interface{ String() string }\ntype Name struct{}\nfunc (*Name) String() string { return \"\" }\nfunc Print(n Namer) {}\n",
+			},
+			afterVersion: "0.2.0",
+			afterOutput:  []string{"changed exported function Print: parameter 1 widened from *Name to interface Namer"},
+		},
+		{
+			name: "replace a value parameter with an interface of its value methods with -smart-widen (minor)",
+			args: []string{"-smart-widen"},
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Namer interface{ String() string }\ntype Name struct{}\nfunc (Name) String() string { return \"\" }\nfunc Print(n Name) {}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Namer interface{ String() string }\ntype Name struct{}\nfunc (Name) String() string { return \"\" }\nfunc Print(n Namer) {}\n",
+			},
+			afterVersion: "0.2.0",
+			afterOutput:  []string{"changed exported function Print: parameter 1 widened from Name to interface Namer"},
+		},
+		{
+			name: "replace a parameter with an interface it does not satisfy with -smart-widen (major)",
+			args: []string{"-smart-widen"},
+			beforeFiles: map[string]string{
+				"test.go": "package main\nimport \"strings\"\nfunc Parse(r *strings.Builder) error { return nil }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nimport \"io\"\nfunc Parse(r io.Reader) error { return nil }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported function Parse: parameter 1 type changed from *strings.Builder to io.Reader"},
+		},
+		{
+			name: "add a method in a different file than its type (minor)",
+			beforeFiles: map[string]string{
+				"a.go": "package main\ntype Test struct{}\n",
+				"b.go": "package main\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"a.go": "package main\ntype Test struct{}\n",
+				"b.go": "package main\nfunc (Test) M() {}\n",
+			},
+			afterVersion: "0.2.0",
+			afterOutput:  []string{"added exported method Test.M"},
+		},
+		{
+			name: "remove a method declared in a different file than its type (major)",
+			beforeFiles: map[string]string{
+				"a.go": "package main\ntype Test struct{}\n",
+				"b.go": "package main\nfunc (Test) M() {}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"a.go": "package main\ntype Test struct{}\n",
+				"b.go": "package main\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"removed exported method Test.M"},
+		},
+		{
+			name: "remove a method from another file that satisfied an interface (major)",
+			beforeFiles: map[string]string{
+				"a.go": "package main\ntype Namer interface{ Name() string }\ntype Test struct{}\n",
+				"b.go": "package main\nfunc (*Test) Name() string { return \"\" }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"a.go": "package main\ntype Namer interface{ Name() string }\ntype Test struct{}\n",
+				"b.go": "package main\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"removed exported method Test.Name", "changed exported type Test: no longer implements Namer"},
+		},
+		{
+			name: "remove a symbol of a generated file with -ignore-generated (patch)",
+			args: []string{"-ignore-generated"},
+			beforeFiles: map[string]string{
+				"test.go":   "package main\nfunc Run() {}\n",
+				"zz_gen.go": "// Code generated by mockgen. DO NOT EDIT.\n\npackage main\nfunc NewMock() {}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nfunc Run() {}\n",
+			},
+			afterVersion: "0.1.1",
+		},
+		{
+			name: "remove a symbol of a generated file (major)",
+			beforeFiles: map[string]string{
+				"test.go":   "package main\nfunc Run() {}\n",
+				"zz_gen.go": "// Code generated by mockgen. DO NOT EDIT.\n\npackage main\nfunc NewMock() {}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nfunc Run() {}\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"removed exported function NewMock"},
+		},
+		{
+			name: "replace a named field type with an anonymous struct of the same shape (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Settings struct{ Name string }\ntype Config struct{ Settings Settings }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Settings struct{ Name string }\ntype Config struct{ Settings struct{ Name string } }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type Config: field Settings type changed: named type Settings replaced by an anonymous struct"},
+		},
+		{
+			name: "replace an anonymous struct field type with a named type of the same shape (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Settings struct{ Name string }\ntype Config struct{ Settings *struct{ Name string } }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Settings struct{ Name string }\ntype Config struct{ Settings *Settings }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type Config: field Settings type changed: anonymous struct replaced by named type Settings"},
+		},
+		{
+			name: "embed a second type reaching the same embedded type (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Conn struct{}\nfunc (Conn) Close() error { return nil }\ntype Reader struct{ Conn }\ntype Writer struct{ Conn }\ntype Pipe struct{ Reader }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Conn struct{}\nfunc (Conn) Close() error { return nil }\ntype Reader struct{ Conn }\ntype Writer struct{ Conn }\ntype Pipe struct{ Reader; Writer }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"removed exported method Pipe.Close"},
+		},
+		{
+			name: "change the length of a named array (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Key [32]byte\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Key [16]byte\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type Key: array length changed from 32 to 16"},
+		},
+		{
+			name: "change the element type of a named array (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Key [32]byte\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Key [32]uint16\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type Key: array element type changed from byte to uint16"},
+		},
+		{
+			name: "change the element type of a named slice (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Bytes []byte\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Bytes []rune\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type Bytes: slice element type changed from byte to rune"},
+		},
+		{
+			name: "change the element of a named slice to a pointer (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Item struct{}\ntype Items []Item\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Item struct{}\ntype Items []*Item\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type Items: slice element type changed from Item to *Item"},
+		},
+		{
+			name: "change a named array to a slice (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Key [32]byte\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Key []byte\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type Key: kind changed from array to slice"},
+		},
+		{
+			name: "add a pointer to an exported field (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nimport \"net\"\ntype Client struct{ Conn net.Conn }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nimport \"net\"\ntype Client struct{ Conn *net.Conn }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"field Conn type changed: added pointer indirection"},
+		},
+		{
+			name: "remove a pointer from an exported field (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Item struct{}\ntype List struct{ Items []*Item }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Item struct{}\ntype List struct{ Items []Item }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"field Items type changed: removed pointer indirection"},
+		},
+		{
+			name: "add a slice field to a comparable struct (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Key struct{ ID int }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Key struct{ ID int; Data []byte }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type Key: no longer comparable"},
+		},
+		{
+			name: "add an unexported map field to a comparable struct (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype cache map[string]int\ntype Key struct{ ID int }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype cache map[string]int\ntype Key struct{ ID int; values cache }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported type Key: no longer comparable"},
+		},
+		{
+			name: "add a comparable field to a comparable struct (minor)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Key struct{ ID int }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Key struct{ ID int; Name [2]string }\n",
+			},
+			afterVersion: "0.2.0",
+		},
+		{
+			name: "relax the constraint of a generic function (minor)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Integer interface{ ~int | ~int64 }\nfunc Sum[T Integer](values ...T) T { var sum T; return sum }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Integer interface{ ~int | ~int64 }\nfunc Sum[T any](values ...T) T { var sum T; return sum }\n",
+			},
+			afterVersion: "0.2.0",
+			afterOutput:  []string{"changed exported function Sum: type parameter T constraint relaxed from interface{~int | ~int64} to any"},
+		},
+		{
+			name: "tighten the constraint of a generic function (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype number interface{ ~int | ~int64 | ~float64 }\nfunc Sum[T number](values ...T) T { var sum T; return sum }\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype number interface{ ~int | ~int64 }\nfunc Sum[T number](values ...T) T { var sum T; return sum }\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"changed exported function Sum: type parameter T constraint tightened"},
+		},
+		{
+			name: "add a method requirement to a constraint (major)",
+			beforeFiles: map[string]string{
+				"test.go": "package main\nfunc Print[T any](value T) {}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\nfunc Print[T interface{ String() string }](value T) {}\n",
+			},
+			afterVersion: "1.0.0",
+			afterOutput:  []string{"type parameter T constraint tightened from any to interface{String() string}"},
+		},
+		{
+			name: "multiple changes: add unexported field + add exported function -> minor",
+			beforeFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{}\n",
+			},
+			beforeVersion: "0.1.0",
+			afterFiles: map[string]string{
+				"test.go": "package main\ntype Test struct{age int}\nfunc Exported() {}\n",
+			},
+			afterVersion: "0.2.0",
+		},
+	}
+
+	path, err := gexec.Build("github.com/jtarchie/semtype")
+	assert.Expect(err).NotTo(HaveOccurred())
+	defer gexec.CleanupBuildArtifacts()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := NewGomegaWithT(t)
+
+			dir, err := os.MkdirTemp("", "")
+			assert.Expect(err).NotTo(HaveOccurred())
+
+			for filename, contents := range test.beforeFiles {
+				fullPath := filepath.Join(dir, filename)
+
+				dir := filepath.Dir(fullPath)
+				err := os.MkdirAll(dir, os.ModePerm)
+				assert.Expect(err).NotTo(HaveOccurred())
+
+				err = os.WriteFile(fullPath, []byte(contents), 0644)
+				assert.Expect(err).NotTo(HaveOccurred())
+			}
+
+			output := gbytes.NewBuffer()
+			session, err := gexec.Start(exec.Command(path, append([]string{"-dir", dir}, test.args...)...), output, output)
+			assert.Expect(err).NotTo(HaveOccurred())
+			assert.Eventually(session).Should(gexec.Exit(0), fmt.Sprintf("output: %s", output.Contents()))
+			assert.Expect(output).To(gbytes.Say(test.beforeVersion))
+
+			for filename, contents := range test.afterFiles {
+				fullPath := filepath.Join(dir, filename)
+
+				dir := filepath.Dir(fullPath)
+				err := os.MkdirAll(dir, os.ModePerm)
+				assert.Expect(err).NotTo(HaveOccurred())
+
+				err = os.WriteFile(fullPath, []byte(contents), 0644)
+				assert.Expect(err).NotTo(HaveOccurred())
+			}
+
+			// remove any files that were in beforeFiles but not in afterFiles to simulate removal
+			for filename := range test.beforeFiles {
+				if _, ok := test.afterFiles[filename]; !ok {
+					fullPath := filepath.Join(dir, filename)
+					err := os.Remove(fullPath)
+					assert.Expect(err).NotTo(HaveOccurred())
+				}
+			}
+
+			assert.Expect(output.Clear()).NotTo(HaveOccurred())
+			session, err = gexec.Start(exec.Command(path, append([]string{"-dir", dir}, test.args...)...), output, output)
+			assert.Expect(err).NotTo(HaveOccurred())
+			assert.Eventually(session).Should(gexec.Exit(0))
+			assert.Expect(output).To(gbytes.Say(test.afterVersion))
+
+			for _, expected := range test.afterOutput {
+				assert.Expect(string(output.Contents())).To(ContainSubstring(expected))
+			}
+		})
+
+	}
+
+	// Scenarios that need more than two runs, or other flags and files,
+	// share the built binary
+	scenarios := []struct {
+		name string
+		run  func(t *testing.T, path string)
+	}{
+		{"config file", testConfigFile},
+		{"hashed signatures", testHashedSignatures},
+		{"proxy baseline", testProxyBaseline},
+		{"quiet", testQuiet},
+		{"since", testSince},
+		{"max history", testMaxHistory},
+		{"commits since", testCommitsSince},
+		{"bump only", testBumpOnly},
+		{"roots", testRoots},
+		{"prefix", testPrefix},
+		{"state dir", testStateDir},
+		{"single file", testSingleFile},
+		{"github output", testGitHubOutput},
+		{"env out", testEnvOut},
+		{"base", testBase},
+		{"hook", testHook},
+		{"watch", testWatch},
+		{"explain", testExplain},
+		{"verbose diff", testVerboseDiff},
+		{"incremental", testIncremental},
+		{"list symbols", testListSymbols},
+		{"several dirs", testSeveralDirs},
+		{"trace", testTrace},
+		{"baseline state", testBaselineState},
+		{"summary json", testSummaryJSON},
+		{"print config", testPrintConfig},
+		{"positions", testPositions},
+		{"base ref", testBaseRef},
+		{"only", testOnly},
+		{"profile", testProfile},
+		{"interface to struct", testInterfaceToStruct},
+		{"fail on", testFailOn},
+		{"version file", testVersionFile},
+		{"per package", testPerPackage},
+		{"unparseable files", testUnparseableFiles},
+		{"check module path", testCheckModulePath},
+		{"aliased imports across files", testAliasedImportsAcrossFiles},
+		{"import alias of a package name", testImportAliasOfPackageName},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			scenario.run(t, path)
+		})
+	}
+}
+
+func testConfigFile(t *testing.T, path string) {
+	t.Run("config file supplies defaults", func(t *testing.T) {
+		assert := NewGomegaWithT(t)
+
+		dir := t.TempDir()
+		writeFiles(assert, dir, map[string]string{
+			"test.go":      "package main\nfunc Exported() {}\n",
+			"semtype.yaml": "state: " + filepath.Join(dir, "from-config.dat") + "\n",
+		})
+
+		session := runSemtype(assert, path, 0, "-dir", dir)
+		assert.Expect(session.Out).To(gbytes.Say("0.1.0"))
+		assert.Expect(filepath.Join(dir, "from-config.dat")).To(BeAnExistingFile())
+		assert.Expect(filepath.Join(dir, "semtype.dat")).NotTo(BeAnExistingFile())
+	})
+
+	t.Run("command line flag overrides config file", func(t *testing.T) {
+		assert := NewGomegaWithT(t)
+
+		dir := t.TempDir()
+		writeFiles(assert, dir, map[string]string{
+			"test.go":      "package main\nfunc Exported() {}\n",
+			"semtype.yaml": "state: " + filepath.Join(dir, "from-config.dat") + "\n",
+		})
+
+		session := runSemtype(assert, path, 0, "-dir", dir, "-state", filepath.Join(dir, "from-flag.dat"))
+		assert.Expect(session.Out).To(gbytes.Say("0.1.0"))
+		assert.Expect(filepath.Join(dir, "from-flag.dat")).To(BeAnExistingFile())
+		assert.Expect(filepath.Join(dir, "from-config.dat")).NotTo(BeAnExistingFile())
+	})
+
+	t.Run("config file lists are joined with commas", func(t *testing.T) {
+		assert := NewGomegaWithT(t)
+
+		dir := t.TempDir()
+		writeFiles(assert, dir, map[string]string{
+			"semtype.yaml": "only:\n  - Client\n  - \"*Option\"\nmax-history: 3\n",
+		})
+
+		session := runSemtype(assert, path, 0, "-dir", dir, "-print-config")
+		assert.Expect(session.Out).To(gbytes.Say(`"max-history": 3`))
+		assert.Expect(session.Out).To(gbytes.Say(`"only": "Client,\*Option"`))
+	})
+
+	t.Run("config file cannot set the directory", func(t *testing.T) {
+		assert := NewGomegaWithT(t)
+
+		dir := t.TempDir()
+		writeFiles(assert, dir, map[string]string{
+			"semtype.yaml": "dir: elsewhere\n",
+		})
+
+		session := runSemtype(assert, path, 1, "-dir", dir)
+		assert.Expect(session.Err).To(gbytes.Say(`option \\"dir\\" can only be given on the command line`))
+	})
+
+	t.Run("invalid config file is reported", func(t *testing.T) {
+		assert := NewGomegaWithT(t)
+
+		dir := t.TempDir()
+		writeFiles(assert, dir, map[string]string{
+			"semtype.yaml": "unknown: value\n",
+		})
+
+		session := runSemtype(assert, path, 1, "-dir", dir)
+		assert.Expect(session.Err).To(gbytes.Say(`unknown option \\"unknown\\"`))
+	})
+
+	t.Run("misspelled config file keys are reported", func(t *testing.T) {
+		assert := NewGomegaWithT(t)
+
+		dir := t.TempDir()
+		writeFiles(assert, dir, map[string]string{
+			"semtype.yaml": "min-bump: huge\nstrict_order: true\nstat: state.dat\n",
+		})
+
+		session := runSemtype(assert, path, 1, "-dir", dir, "-print-config")
+		assert.Expect(session.Out.Contents()).To(BeEmpty())
+		assert.Expect(session.Err).To(gbytes.Say(`unknown option \\"stat\\"; unknown option \\"strict_order\\", did you mean \\"strict-order\\"`))
+	})
+}
+
+func testHashedSignatures(t *testing.T, path string) {
+	assert := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	writeFiles(assert, dir, map[string]string{
+		"test.go": "package main\nfunc Exported(value int) string { return \"\" }\n",
+	})
+
+	session := runSemtype(assert, path, 0, "-dir", dir, "-full-signatures=false")
+	assert.Expect(session.Out).To(gbytes.Say("0.1.0"))
+
+	contents, err := os.ReadFile(filepath.Join(dir, "semtype.dat"))
+	assert.Expect(err).NotTo(HaveOccurred())
+	assert.Expect(string(contents)).To(ContainSubstring("sha256:"))
+	assert.Expect(string(contents)).NotTo(ContainSubstring("func(int) string"))
+
+	// Added and removed symbols bump like with full signatures
+	writeFiles(assert, dir, map[string]string{
+		"test.go": "package main\nfunc Exported(value int) string { return \"\" }\ntype Config struct{ Name string }\n",
+	})
+	session = runSemtype(assert, path, 0, "-dir", dir, "-full-signatures=false")
+	assert.Expect(session.Out).To(gbytes.Say("0.2.0"))
+
+	// Changes that are minor with full signatures are major with hashes
+	for _, test := range []struct {
+		args    []string
+		version string
+		reason  string
+	}{
+		{args: nil, version: "0.2.0", reason: "changed exported type Config: added field Age"},
+		{args: []string{"-full-signatures=false"}, version: "1.0.0", reason: "changed exported type Config: signature changed"},
+	} {
+		dir := t.TempDir()
+		writeFiles(assert, dir, map[string]string{"test.go": "package main\ntype Config struct{ Name string }\nfunc Load() <-chan int { return nil }\n"})
+		runSemtype(assert, path, 0, append([]string{"-dir", dir, "-quiet"}, test.args...)...)
+
+		writeFiles(assert, dir, map[string]string{"test.go": "package main\ntype Config struct{ Name string; Age int }\nfunc Load() chan int { return nil }\n"})
+		session := runSemtype(assert, path, 0, append([]string{"-dir", dir}, test.args...)...)
+		assert.Expect(session.Out).To(gbytes.Say(test.version))
+		assert.Expect(session.Err).To(gbytes.Say(test.reason))
+	}
+}
+
+func testProxyBaseline(t *testing.T, path string) {
+	assert := NewGomegaWithT(t)
+
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+	for name, contents := range map[string]string{
+		"example.com/lib@v1.2.0/go.mod":  "module example.com/lib\n",
+		"example.com/lib@v1.2.0/test.go": "package lib\nfunc A() {}\nfunc B() {}\n",
+	} {
+		file, err := writer.Create(name)
+		assert.Expect(err).NotTo(HaveOccurred())
+		_, err = file.Write([]byte(contents))
+		assert.Expect(err).NotTo(HaveOccurred())
+	}
+	assert.Expect(writer.Close()).NotTo(HaveOccurred())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/example.com/lib/@latest":
+			_, _ = w.Write([]byte(`{"Version":"v1.2.0"}`))
+		case "/example.com/lib/@v/v1.2.0.zip":
+			_, _ = w.Write(archive.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("compares against the published version", func(t *testing.T) {
+		assert := NewGomegaWithT(t)
+
+		dir := t.TempDir()
+		writeFiles(assert, dir, map[string]string{
+			"go.mod":  "module example.com/lib\n",
+			"test.go": "package lib\nfunc A() {}\n",
+		})
+
+		command := exec.Command(path, "-dir", dir, "-proxy-baseline")
+		command.Env = append(os.Environ(), "GOPROXY="+server.URL)
+		session, err := gexec.Start(command, gbytes.NewBuffer(), gbytes.NewBuffer())
+		assert.Expect(err).NotTo(HaveOccurred())
+		assert.Eventually(session).Should(gexec.Exit(0))
+		assert.Expect(session.Out).To(gbytes.Say("2.0.0"))
+		assert.Expect(session.Err).To(gbytes.Say("removed exported function B"))
+	})
+
+	t.Run("uses an empty baseline for unpublished modules", func(t *testing.T) {
+		assert := NewGomegaWithT(t)
+
+		dir := t.TempDir()
+		writeFiles(assert, dir, map[string]string{
+			"go.mod":  "module example.com/unpublished\n",
+			"test.go": "package lib\nfunc A() {}\n",
+		})
+
+		command := exec.Command(path, "-dir", dir, "-proxy-baseline")
+		command.Env = append(os.Environ(), "GOPROXY="+server.URL)
+		session, err := gexec.Start(command, gbytes.NewBuffer(), gbytes.NewBuffer())
+		assert.Expect(err).NotTo(HaveOccurred())
+		assert.Eventually(session).Should(gexec.Exit(0))
+		assert.Expect(session.Out).To(gbytes.Say("0.1.0"))
+	})
+
+	t.Run("reports network errors", func(t *testing.T) {
+		assert := NewGomegaWithT(t)
+
+		dir := t.TempDir()
+		writeFiles(assert, dir, map[string]string{
+			"go.mod":  "module example.com/lib\n",
+			"test.go": "package lib\nfunc A() {}\n",
+		})
+
+		command := exec.Command(path, "-dir", dir, "-proxy-baseline")
+		command.Env = append(os.Environ(), "GOPROXY=http://127.0.0.1:1")
+		session, err := gexec.Start(command, gbytes.NewBuffer(), gbytes.NewBuffer())
+		assert.Expect(err).NotTo(HaveOccurred())
+		assert.Eventually(session).Should(gexec.Exit(1))
+		assert.Expect(session.Err).To(gbytes.Say("loading baseline from module proxy"))
+	})
+
+	t.Run("never falls back to the default proxy", func(t *testing.T) {
+		assert := NewGomegaWithT(t)
+
+		dir := t.TempDir()
+		writeFiles(assert, dir, map[string]string{
+			"go.mod":  "module example.com/lib\n",
+			"test.go": "package lib\nfunc A() {}\n",
+		})
+
+		for goproxy, message := range map[string]string{
+			"off":        "GOPROXY=off disables the module proxy",
+			"direct":     "GOPROXY=direct lists no module proxy",
+			"direct,off": "GOPROXY=direct,off disables the module proxy",
+		} {
+			command := exec.Command(path, "-dir", dir, "-proxy-baseline")
+			command.Env = append(os.Environ(), "GOPROXY="+goproxy)
+			session, err := gexec.Start(command, gbytes.NewBuffer(), gbytes.NewBuffer())
+			assert.Expect(err).NotTo(HaveOccurred())
+			assert.Eventually(session).Should(gexec.Exit(1))
+			assert.Expect(session.Err).To(gbytes.Say(message))
+		}
+	})
+}
+
+func testQuiet(t *testing.T, path string) {
+	assert := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	writeFiles(assert, dir, map[string]string{
+		"test.go":   "package main\nfunc Exported() {}\n",
+		"broken.go": "package main\nfunc Broken( {\n",
+	})
+
+	session := runSemtype(assert, path, 0, "-dir", dir, "-quiet")
+	assert.Expect(session.Out.Contents()).To(Equal([]byte("0.1.0\n")))
+	assert.Expect(session.Err.Contents()).To(BeEmpty())
+
+	session = runSemtype(assert, path, 1, "-dir", dir, "-quiet", "-strict-parse")
+	assert.Expect(session.Err).To(gbytes.Say("execution failed"))
+}
+
+func testSince(t *testing.T, path string) {
+	assert := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	writeFiles(assert, dir, map[string]string{"test.go": "package main\nfunc A() {}\n"})
+	session := runSemtype(assert, path, 0, "-dir", dir)
+	assert.Expect(session.Out).To(gbytes.Say("0.1.0"))
+
+	writeFiles(assert, dir, map[string]string{"test.go": "package main\nfunc A() {}\nfunc B() {}\n"})
+	session = runSemtype(assert, path, 0, "-dir", dir)
+	assert.Expect(session.Out).To(gbytes.Say("0.2.0"))
+
+	writeFiles(assert, dir, map[string]string{"test.go": "package main\nfunc B() {}\nfunc C() {}\n"})
+	session = runSemtype(assert, path, 0, "-dir", dir, "-since", "0.1.0")
+	output := string(session.Out.Contents())
+	assert.Expect(output).To(ContainSubstring("major: removed exported function A"))
+	assert.Expect(output).To(ContainSubstring("minor: added exported function B"))
+	assert.Expect(output).To(ContainSubstring("minor: added exported function C"))
+
+	session = runSemtype(assert, path, 0, "-dir", dir, "-since", "0.2.0")
+	output = string(session.Out.Contents())
+	assert.Expect(output).To(ContainSubstring("removed exported function A"))
+	assert.Expect(output).NotTo(ContainSubstring("function B"))
+
+	session = runSemtype(assert, path, 1, "-dir", dir, "-since", "0.0.1")
+	assert.Expect(session.Err).To(gbytes.Say("no snapshot recorded for version 0.0.1"))
+
+	// reporting does not change the state
+	session = runSemtype(assert, path, 0, "-dir", dir)
+	assert.Expect(session.Out).To(gbytes.Say("1.0.0"))
+}
+
+func testMaxHistory(t *testing.T, path string) {
+	assert := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	functions := "package main\n"
+	for _, release := range []struct{ function, version string }{
+		{"A", "0.1.0"},
+		{"B", "0.2.0"},
+		{"C", "0.3.0"},
+		{"D", "0.4.0"},
+	} {
+		functions += "func " + release.function + "() {}\n"
+		writeFiles(assert, dir, map[string]string{"test.go": functions})
+		session := runSemtype(assert, path, 0, "-dir", dir, "-max-history", "2")
+		assert.Expect(session.Out).To(gbytes.Say(release.version))
+	}
+
+	// only the two versions before the current one are kept
+	session := runSemtype(assert, path, 1, "-dir", dir, "-since", "0.1.0")
+	assert.Expect(session.Err).To(gbytes.Say("no snapshot recorded for version 0.1.0"))
+	runSemtype(assert, path, 0, "-dir", dir, "-since", "0.2.0")
+	runSemtype(assert, path, 0, "-dir", dir, "-since", "0.3.0")
+
+	session = runSemtype(assert, path, 1, "-dir", dir, "-max-history", "-1")
+	assert.Expect(session.Err).To(gbytes.Say("-max-history must not be negative"))
+}
+
+func testCommitsSince(t *testing.T, path string) {
+	assert := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		command := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		output, err := command.CombinedOutput()
+		assert.Expect(err).NotTo(HaveOccurred(), string(output))
+	}
+
+	git("init", "-q")
+	writeFiles(assert, dir, map[string]string{"test.go": "package main\nfunc Exported() {}\n"})
+	git("add", "test.go")
+	git("commit", "-q", "-m", "initial commit")
+	git("tag", "base")
+
+	session := runSemtype(assert, path, 0, "-dir", dir, "-commits-since", "base")
+	assert.Expect(session.Out).To(gbytes.Say("0.1.0"))
+
+	writeFiles(assert, dir, map[string]string{"test.go": "package main\nfunc Exported() { println() }\n"})
+	git("commit", "-q", "-am", "fix: print a newline")
+	session = runSemtype(assert, path, 0, "-dir", dir, "-commits-since", "base")
+	assert.Expect(session.Out).To(gbytes.Say("0.1.1"))
+
+	git("commit", "-q", "--allow-empty", "-m", "feat(printer): support printing")
+	session = runSemtype(assert, path, 0, "-dir", dir, "-commits-since", "base")
+	assert.Expect(session.Out).To(gbytes.Say("0.2.0"))
+
+	git("commit", "-q", "--allow-empty", "-m", "refactor: rework printing\n\nBREAKING CHANGE: printing is gone")
+	session = runSemtype(assert, path, 0, "-dir", dir, "-commits-since", "base")
+	assert.Expect(session.Out).To(gbytes.Say("1.0.0"))
+
+	session = runSemtype(assert, path, 1, "-dir", dir, "-commits-since", "missing")
+	assert.Expect(session.Err).To(gbytes.Say("reading git log since missing"))
+}
+
+func testBumpOnly(t *testing.T, path string) {
+	tests := []struct {
+		name  string
+		after string
+		bump  string
+	}{
+		{name: "body change", after: "package main\nfunc Exported() { println() }\n", bump: "patch"},
+		{name: "added function", after: "package main\nfunc Exported() {}\nfunc Added() {}\n", bump: "minor"},
+		{name: "removed function", after: "package main\nfunc Other() {}\n", bump: "major"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := NewGomegaWithT(t)
+
+			dir := t.TempDir()
+			writeFiles(assert, dir, map[string]string{"test.go": "package main\nfunc Exported() {}\n"})
+			session := runSemtype(assert, path, 0, "-dir", dir)
+			assert.Expect(session.Out.Contents()).To(Equal([]byte("0.1.0\n")))
+
+			writeFiles(assert, dir, map[string]string{"test.go": test.after})
+			session = runSemtype(assert, path, 0, "-dir", dir, "-bump-only", "-dry-run")
+			assert.Expect(session.Out.Contents()).To(Equal([]byte(test.bump + "\n")))
+
+			// A dry run leaves the state file untouched
+			session = runSemtype(assert, path, 0, "-dir", dir, "-bump-only")
+			assert.Expect(session.Out.Contents()).To(Equal([]byte(test.bump + "\n")))
+		})
+	}
+}
+
+func testRoots(t *testing.T, path string) {
+	files := map[string]string{
+		"lib/lib.go":       "package lib\nfunc Open() {}\n",
+		"pkg/pkg.go":       "package pkg\nfunc Open() {}\nfunc Close() {}\n",
+		"cmd/tool/main.go": "package main\nfunc Run() {}\n",
+	}
+
+	removals := map[string]string{
+		"lib/lib.go": "package lib\n",
+		"pkg/pkg.go": "package pkg\nfunc Close() {}\n",
+	}
+
+	for root, contents := range removals {
+		t.Run("removing a symbol from "+root, func(t *testing.T) {
+			assert := NewGomegaWithT(t)
+
+			dir := t.TempDir()
+			writeFiles(assert, dir, files)
+			session := runSemtype(assert, path, 0, "-dir", dir, "-roots", "lib,pkg")
+			assert.Expect(session.Out).To(gbytes.Say("0.1.0"))
+
+			// Command packages are not part of the public API
+			writeFiles(assert, dir, map[string]string{"cmd/tool/main.go": "package main\n"})
+			session = runSemtype(assert, path, 0, "-dir", dir, "-roots", "lib,pkg")
+			assert.Expect(session.Out).To(gbytes.Say("0.1.1"))
+
+			writeFiles(assert, dir, map[string]string{root: contents})
+			session = runSemtype(assert, path, 0, "-dir", dir, "-roots", "lib,pkg")
+			assert.Expect(session.Out).To(gbytes.Say("1.0.0"))
+			assert.Expect(session.Err).To(gbytes.Say("removed exported function " + filepath.Dir(root) + ".Open"))
+		})
+	}
+}
+
+func testPrefix(t *testing.T, path string) {
+	t.Run("prefixes the printed version only", func(t *testing.T) {
+		assert := NewGomegaWithT(t)
+
+		dir := t.TempDir()
+		writeFiles(assert, dir, map[string]string{"test.go": "package main\n"})
+		session := runSemtype(assert, path, 0, "-dir", dir, "-prefix", "v")
+		assert.Expect(session.Out.Contents()).To(Equal([]byte("v0.0.1\n")))
+
+		session = runSemtype(assert, path, 0, "-dir", dir, "-prefix", "v")
+		assert.Expect(session.Out.Contents()).To(Equal([]byte("v0.0.2\n")))
+
+		session = runSemtype(assert, path, 0, "-dir", dir)
+		assert.Expect(session.Out.Contents()).To(Equal([]byte("0.0.3\n")))
+	})
+
+	t.Run("accepts a prefixed initial version", func(t *testing.T) {
+		assert := NewGomegaWithT(t)
+
+		dir := t.TempDir()
+		writeFiles(assert, dir, map[string]string{"test.go": "package main\nfunc Exported() {}\n"})
+		session := runSemtype(assert, path, 0, "-dir", dir, "-initial", "v1.4.0", "-prefix", "v")
+		assert.Expect(session.Out.Contents()).To(Equal([]byte("v1.5.0\n")))
+		assert.Expect(session.Err).NotTo(gbytes.Say("failed to parse version"))
+
+		session = runSemtype(assert, path, 0, "-dir", dir)
+		assert.Expect(session.Out.Contents()).To(Equal([]byte("1.5.1\n")))
+	})
+}
+
+func testStateDir(t *testing.T, path string) {
+	assert := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	stateDir := t.TempDir()
+	writeFiles(assert, dir, map[string]string{"test.go": "package main\nfunc Exported() {}\n"})
+
+	session := runSemtype(assert, path, 0, "-dir", dir, "-state-dir", stateDir)
+	assert.Expect(session.Out).To(gbytes.Say("0.1.0"))
+	assert.Expect(filepath.Join(stateDir, "semtype.dat")).To(BeAnExistingFile())
+	assert.Expect(filepath.Join(dir, "semtype.dat")).NotTo(BeAnExistingFile())
+
+	writeFiles(assert, dir, map[string]string{"test.go": "package main\nfunc Exported() {}\nfunc Added() {}\n"})
+	session = runSemtype(assert, path, 0, "-dir", dir, "-state-dir", stateDir)
+	assert.Expect(session.Out).To(gbytes.Say("0.2.0"))
+
+	// A state directory within the analyzed tree is not mistaken for a package
+	session = runSemtype(assert, path, 0, "-dir", dir, "-state-dir", filepath.Join(dir, "state"), "-recursive")
+	assert.Expect(session.Out).To(gbytes.Say("0.1.0"))
+	session = runSemtype(assert, path, 0, "-dir", dir, "-state-dir", filepath.Join(dir, "state"), "-recursive")
+	assert.Expect(session.Out).To(gbytes.Say("0.1.1"))
+}
+
+func testSingleFile(t *testing.T, path string) {
+	assert := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	writeFiles(assert, dir, map[string]string{
+		"tool.go":  "package main\nfunc Run() {}\n",
+		"other.go": "package main\nfunc Other() {}\n",
+	})
+	file := filepath.Join(dir, "tool.go")
+
+	session := runSemtype(assert, path, 0, "-file", file)
+	assert.Expect(session.Out).To(gbytes.Say("0.1.0"))
+	assert.Expect(session.Err).NotTo(gbytes.Say("Other"))
+	assert.Expect(filepath.Join(dir, "semtype.dat")).To(BeAnExistingFile())
+
+	writeFiles(assert, dir, map[string]string{"tool.go": "package main\nfunc Run(args []string) {}\n"})
+	session = runSemtype(assert, path, 0, "-file", file, "-dir", dir)
+	assert.Expect(session.Out).To(gbytes.Say("1.0.0"))
+
+	session = runSemtype(assert, path, 1, "-file", file, "-dir", t.TempDir())
+	assert.Expect(session.Err).To(gbytes.Say("is not part of the package in -dir"))
+}
+
+func testGitHubOutput(t *testing.T, path string) {
+	assert := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	outputPath := filepath.Join(t.TempDir(), "output")
+	writeFiles(assert, dir, map[string]string{"test.go": "package main\nfunc Exported() {}\n"})
+
+	command := exec.Command(path, "-dir", dir, "-github-output", "-prefix", "v")
+	command.Env = append(os.Environ(), "GITHUB_OUTPUT="+outputPath)
+	session, err := gexec.Start(command, gbytes.NewBuffer(), gbytes.NewBuffer())
+	assert.Expect(err).NotTo(HaveOccurred())
+	assert.Eventually(session).Should(gexec.Exit(0))
+	assert.Expect(session.Out).To(gbytes.Say("v0.1.0"))
+
+	contents, err := os.ReadFile(outputPath)
+	assert.Expect(err).NotTo(HaveOccurred())
+	assert.Expect(string(contents)).To(Equal("version=v0.1.0\nbump=minor\n"))
+
+	command = exec.Command(path, "-dir", dir, "-github-output")
+	command.Env = append(os.Environ(), "GITHUB_OUTPUT=")
+	session, err = gexec.Start(command, gbytes.NewBuffer(), gbytes.NewBuffer())
+	assert.Expect(err).NotTo(HaveOccurred())
+	assert.Eventually(session).Should(gexec.Exit(1))
+	assert.Expect(session.Err).To(gbytes.Say("GITHUB_OUTPUT is not set"))
+}
+
+func testEnvOut(t *testing.T, path string) {
+	assert := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	envPath := filepath.Join(t.TempDir(), "semtype.env")
+	writeFiles(assert, dir, map[string]string{"test.go": "package main\nfunc Exported() {}\n"})
+
+	session := runSemtype(assert, path, 0, "-dir", dir, "-env-out", envPath, "-prefix", "$(exit 1)'v")
+	assert.Expect(session.Out).To(gbytes.Say(`\$\(exit 1\)'v0\.1\.0`))
+
+	contents, err := os.ReadFile(envPath)
+	assert.Expect(err).NotTo(HaveOccurred())
+	assert.Expect(string(contents)).To(Equal("SEMTYPE_VERSION='$(exit 1)'\\''v0.1.0'\nSEMTYPE_BUMP='minor'\n"))
+
+	command := exec.Command("sh", "-c", ". "+envPath+" && echo \"$SEMTYPE_VERSION $SEMTYPE_BUMP\"")
+	output, err := command.Output()
+	assert.Expect(err).NotTo(HaveOccurred())
+	assert.Expect(string(output)).To(Equal("$(exit 1)'v0.1.0 minor\n"))
+}
+
+func testBase(t *testing.T, path string) {
+	assert := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	writeFiles(assert, dir, map[string]string{
+		"old/test.go": "package main\nfunc Kept() {}\nfunc Removed() {}\n",
+		"new/test.go": "package main\nfunc Kept() {}\n",
+	})
+
+	session := runSemtype(assert, path, 0, "-base", filepath.Join(dir, "old"), "-dir", filepath.Join(dir, "new"), "-initial", "1.2.3")
+	assert.Expect(session.Out.Contents()).To(Equal([]byte("2.0.0\n")))
+	assert.Expect(session.Err).To(gbytes.Say("removed exported function Removed"))
+	assert.Expect(filepath.Join(dir, "new", "semtype.dat")).NotTo(BeAnExistingFile())
+
+	session = runSemtype(assert, path, 0, "-base", filepath.Join(dir, "old"), "-dir", filepath.Join(dir, "old"), "-initial", "1.2.3")
+	assert.Expect(session.Out.Contents()).To(Equal([]byte("1.2.4\n")))
+}
+
+func testHook(t *testing.T, path string) {
+	assert := NewGomegaWithT(t)
+
+	hooks := t.TempDir()
+	writeFiles(assert, hooks, map[string]string{
+		"ci.sh":      "#!/bin/sh\nsed -n 's/.*\"version\":\"\\([^\"]*\\)\".*/\\1+ci/p'\n",
+		"invalid.sh": "#!/bin/sh\necho not-a-version\n",
+	})
+	for _, hook := range []string{"ci.sh", "invalid.sh"} {
+		assert.Expect(os.Chmod(filepath.Join(hooks, hook), 0o755)).To(Succeed())
+	}
+
+	dir := t.TempDir()
+	writeFiles(assert, dir, map[string]string{"test.go": "package main\nfunc Exported() {}\n"})
+
+	session := runSemtype(assert, path, 0, "-dir", dir, "-hook", filepath.Join(hooks, "ci.sh"))
+	assert.Expect(session.Out.Contents()).To(Equal([]byte("0.1.0+ci\n")))
+
+	session = runSemtype(assert, path, 0, "-dir", dir)
+	assert.Expect(session.Out.Contents()).To(Equal([]byte("0.1.1\n")))
+
+	session = runSemtype(assert, path, 1, "-dir", dir, "-hook", filepath.Join(hooks, "invalid.sh"))
+	assert.Expect(session.Err).To(gbytes.Say(`invalid version`))
+}
+
+func testWatch(t *testing.T, path string) {
+	assert := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	writeFiles(assert, dir, map[string]string{"test.go": "package main\n"})
+
+	session, err := gexec.Start(exec.Command(path, "-dir", dir, "-watch"), gbytes.NewBuffer(), gbytes.NewBuffer())
+	assert.Expect(err).NotTo(HaveOccurred())
+	defer session.Kill()
+
+	assert.Eventually(session.Out).Should(gbytes.Say("0.0.1\n"))
+
+	writeFiles(assert, dir, map[string]string{"test.go": "package main\nfunc Exported() {}\n"})
+	assert.Eventually(session.Out, "5s").Should(gbytes.Say("0.1.0\n"))
+	assert.Expect(filepath.Join(dir, "semtype.dat")).NotTo(BeAnExistingFile())
+}
+
+func testExplain(t *testing.T, path string) {
+	assert := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	writeFiles(assert, dir, map[string]string{"test.go": "package main\nfunc Parse() {}\nfunc A() {}\n"})
+	session := runSemtype(assert, path, 0, "-dir", dir, "-explain", "-quiet")
+	assert.Expect(session.Err).To(gbytes.Say(`Bumping 0.0.0 → 0.1.0 \(minor\): added exported function A; added exported function Parse\n`))
+
+	writeFiles(assert, dir, map[string]string{"test.go": "package main\nfunc A() {}\nfunc B() {}\nfunc C() {}\nfunc D() {}\n"})
+	session = runSemtype(assert, path, 0, "-dir", dir, "-explain", "-quiet")
+	assert.Expect(session.Out.Contents()).To(Equal([]byte("1.0.0\n")))
+	assert.Expect(session.Err).To(gbytes.Say(`Bumping 0.1.0 → 1.0.0 \(major\): removed exported function Parse; added exported function B; added exported function C, and 1 more\n`))
+}
+
+func testVerboseDiff(t *testing.T, path string) {
+	assert := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	writeFiles(assert, dir, map[string]string{"test.go": "package main\nfunc Parse(s string) int { return 0 }\nfunc Load() {}\n"})
+	runSemtype(assert, path, 0, "-dir", dir, "-quiet")
+
+	writeFiles(assert, dir, map[string]string{"test.go": "package main\nfunc Parse(s string) (int64, error) { return 0, nil }\nfunc Load() {}\nfunc Save() {}\n"})
+	session := runSemtype(assert, path, 0, "-dir", dir, "-verbose-diff", "-quiet")
+	assert.Expect(session.Out.Contents()).To(Equal([]byte("1.0.0\n")))
+	assert.Expect(session.Err.Contents()).To(Equal([]byte("@@ Parse @@\n-func(string) int\n+func(string) (int64, error)\n")))
+}
+
+func testIncremental(t *testing.T, path string) {
+	assert := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	envPath := filepath.Join(t.TempDir(), "semtype.env")
+	writeFiles(assert, dir, map[string]string{"test.go": "package main\nfunc A() {}\n"})
+	session := runSemtype(assert, path, 0, "-dir", dir, "-incremental")
+	assert.Expect(session.Out.Contents()).To(Equal([]byte("0.1.0\n")))
+	assert.Expect(session.Err).NotTo(gbytes.Say("skipping analysis"))
+
+	session = runSemtype(assert, path, 0, "-dir", dir, "-incremental", "-env-out", envPath)
+	assert.Expect(session.Out.Contents()).To(Equal([]byte("0.1.0\n")))
+	assert.Expect(session.Err).To(gbytes.Say("skipping analysis, as the inputs are unchanged"))
+
+	contents, err := os.ReadFile(envPath)
+	assert.Expect(err).NotTo(HaveOccurred())
+	assert.Expect(string(contents)).To(Equal("SEMTYPE_VERSION='0.1.0'\nSEMTYPE_BUMP='minor'\n"))
+
+	session = runSemtype(assert, path, 0, "-dir", dir, "-incremental", "-force")
+	assert.Expect(session.Out.Contents()).To(Equal([]byte("0.1.1\n")))
+
+	writeFiles(assert, dir, map[string]string{"go.mod": "module example.com/test\n\ngo 1.23\n"})
+	session = runSemtype(assert, path, 0, "-dir", dir, "-incremental")
+	assert.Expect(session.Out.Contents()).To(Equal([]byte("0.1.2\n")))
+	assert.Expect(session.Err).NotTo(gbytes.Say("skipping analysis"))
+
+	session = runSemtype(assert, path, 0, "-dir", dir, "-incremental", "-track-tags")
+	assert.Expect(session.Out.Contents()).To(Equal([]byte("0.1.3\n")))
+}
+
+func testListSymbols(t *testing.T, path string) {
+	assert := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	writeFiles(assert, dir, map[string]string{"test.go": "package main\ntype Client struct{ Name string }\nfunc (c *Client) Close() error { return nil }\nfunc Parse(s string) int { return 0 }\nfunc helper() {}\nvar Default = &Client{}\n"})
+	session := runSemtype(assert, path, 0, "-dir", dir, "-list-symbols")
+	assert.Expect(string(session.Out.Contents())).To(Equal("type Client struct{ Name string }\nfunction Parse func(string) int\nmethod Client.Close func(*Client) error\nvariable Default *Client\n"))
+	assert.Expect(filepath.Join(dir, "semtype.dat")).NotTo(BeAnExistingFile())
+}
+
+func testSeveralDirs(t *testing.T, path string) {
+	assert := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	semtype := func(files map[string]string, args ...string) *gexec.Session {
+		writeFiles(assert, dir, files)
+		command := exec.Command(path, append([]string{"-dir", "lib/a,lib/b"}, args...)...)
+		command.Dir = dir
+		session, err := gexec.Start(command, gbytes.NewBuffer(), gbytes.NewBuffer())
+		assert.Expect(err).NotTo(HaveOccurred())
+		assert.Eventually(session).Should(gexec.Exit(0))
+		return session
+	}
+
+	session := semtype(map[string]string{
+		"lib/a/a.go": "package a\nfunc New() {}\n",
+		"lib/b/b.go": "package b\nfunc New() {}\nfunc Close() {}\n",
+	})
+	assert.Expect(session.Out).To(gbytes.Say("0.1.0"))
+	assert.Expect(filepath.Join(dir, "lib", "a", "semtype.dat")).To(BeAnExistingFile())
+
+	session = semtype(map[string]string{}, "-list-symbols")
+	assert.Expect(string(session.Out.Contents())).To(Equal("function lib/a.New func()\nfunction lib/b.Close func()\nfunction lib/b.New func()\n"))
+
+	session = semtype(map[string]string{"lib/b/b.go": "package b\nfunc New() {}\n"})
+	assert.Expect(session.Out).To(gbytes.Say("1.0.0"))
+	assert.Expect(session.Err).To(gbytes.Say("removed exported function lib/b.Close"))
+
+	session = runSemtype(assert, path, 1, "-dir", "a,b", "-roots", "c")
+	assert.Expect(session.Err).To(gbytes.Say("cannot be combined with"))
+}
+
+func testTrace(t *testing.T, path string) {
+	assert := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	writeFiles(assert, dir, map[string]string{"test.go": "package main\nfunc helper() {}\nfunc Run() {}\n"})
+
+	session := runSemtype(assert, path, 0, "-dir", dir, "-dry-run")
+	assert.Expect(session.Err).NotTo(gbytes.Say("skipping function"))
+
+	session = runSemtype(assert, path, 0, "-dir", dir, "-dry-run", "-trace")
+	assert.Expect(session.Err).To(gbytes.Say(`"msg":"skipping function","name":"helper","reason":"unexported"`))
+	assert.Expect(session.Err).To(gbytes.Say(`"msg":"recording function","name":"Run"`))
+}
+
+func testBaselineState(t *testing.T, path string) {
+	assert := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	baselinePath := filepath.Join(t.TempDir(), "baseline.dat")
+	statePath := filepath.Join(t.TempDir(), "branch.dat")
+
+	writeFiles(assert, dir, map[string]string{"test.go": "package main\nfunc A() {}\n"})
+	runSemtype(assert, path, 0, "-dir", dir, "-state", baselinePath, "-quiet")
+	baseline, err := os.ReadFile(baselinePath)
+	assert.Expect(err).NotTo(HaveOccurred())
+
+	writeFiles(assert, dir, map[string]string{"test.go": "package main\nfunc A() {}\nfunc B() {}\n"})
+	for range 2 {
+		session := runSemtype(assert, path, 0, "-dir", dir, "-baseline-state", baselinePath, "-state", statePath, "-quiet")
+		assert.Expect(session.Out.Contents()).To(Equal([]byte("0.2.0\n")))
+	}
+
+	contents, err := os.ReadFile(baselinePath)
+	assert.Expect(err).NotTo(HaveOccurred())
+	assert.Expect(contents).To(Equal(baseline))
+	assert.Expect(statePath).To(BeAnExistingFile())
+
+	session := runSemtype(assert, path, 0, "-dir", dir, "-state", statePath, "-quiet")
+	assert.Expect(session.Out.Contents()).To(Equal([]byte("0.2.1\n")))
+
+	session = runSemtype(assert, path, 1, "-dir", dir, "-baseline-state", baselinePath, "-state", baselinePath)
+	assert.Expect(session.Err).To(gbytes.Say("-baseline-state must differ from -state"))
+}
+
+func testSummaryJSON(t *testing.T, path string) {
+	assert := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	writeFiles(assert, dir, map[string]string{"test.go": "package main\nfunc A() {}\nfunc B(int) {}\nfunc C(int) {}\n"})
+	session := runSemtype(assert, path, 0, "-dir", dir, "-summary-json", "-quiet")
+	assert.Expect(string(session.Out.Contents())).To(MatchJSON(`{"added":3,"removed":0,"changed":0,"bump":"minor","version":"0.1.0"}`))
+
+	writeFiles(assert, dir, map[string]string{"test.go": "package main\nfunc B(string) {}\nfunc C(int64) {}\nfunc D() {}\nfunc E() {}\nfunc F() {}\n"})
+	session = runSemtype(assert, path, 0, "-dir", dir, "-summary-json", "-prefix", "v", "-quiet")
+	assert.Expect(string(session.Out.Contents())).To(MatchJSON(`{"added":3,"removed":1,"changed":2,"bump":"major","version":"v1.0.0"}`))
+}
+
+func testPrintConfig(t *testing.T, path string) {
+	assert := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	writeFiles(assert, dir, map[string]string{
+		"test.go":      "package main\nfunc A() {}\n",
+		"semtype.yaml": "prefix: v\nmin-bump: minor\n",
+	})
+
+	session := runSemtype(assert, path, 0, "-dir", dir, "-print-config", "-min-bump", "major", "-max-history", "3")
+	var values map[string]any
+	assert.Expect(json.Unmarshal(session.Out.Contents(), &values)).To(Succeed())
+	assert.Expect(values).To(HaveKeyWithValue("min-bump", "major"))
+	assert.Expect(values).To(HaveKeyWithValue("max-history", BeNumerically("==", 3)))
+	assert.Expect(values).To(HaveKeyWithValue("prefix", "v"))
+	assert.Expect(values).To(HaveKeyWithValue("state", filepath.Join(dir, "semtype.dat")))
+	assert.Expect(values).To(HaveKeyWithValue("track-tags", false))
+	assert.Expect(filepath.Join(dir, "semtype.dat")).NotTo(BeAnExistingFile())
+}
+
+func testPositions(t *testing.T, path string) {
+	assert := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	writeFiles(assert, dir, map[string]string{"test.go": "package main\n\nfunc Run() {}\n\nfunc Stop() {}\n"})
+	session := runSemtype(assert, path, 0, "-dir", dir)
+	assert.Expect(session.Err).NotTo(gbytes.Say(`"position"`))
+
+	session = runSemtype(assert, path, 0, "-dir", dir, "-positions")
+	assert.Expect(session.Err).NotTo(gbytes.Say(`"position"`))
+
+	writeFiles(assert, dir, map[string]string{"test.go": "package main\n\n\nfunc Run(int) {}\n"})
+	session = runSemtype(assert, path, 0, "-dir", dir, "-positions")
+	assert.Expect(session.Err).To(gbytes.Say(`"symbol":"Run".*"position":"test.go:4:6"`))
+	assert.Expect(session.Err).To(gbytes.Say(`"symbol":"Stop".*"position":"test.go:5:6"`))
+}
+
+func testBaseRef(t *testing.T, path string) {
+	assert := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		command := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		output, err := command.CombinedOutput()
+		assert.Expect(err).NotTo(HaveOccurred(), string(output))
+	}
+
+	git("init", "-q")
+	writeFiles(assert, dir, map[string]string{"lib/lib.go": "package lib\nfunc A() {}\n"})
+	git("add", ".")
+	git("commit", "-q", "-m", "initial commit")
+	git("tag", "v1.2.0")
+
+	writeFiles(assert, dir, map[string]string{"lib/lib.go": "package lib\nfunc A() {}\nfunc B() {}\n"})
+	git("commit", "-q", "-am", "add B")
+	git("tag", "v1.3.0")
+
+	// the working tree is neither analyzed nor changed
+	writeFiles(assert, dir, map[string]string{"lib/lib.go": "package lib\n"})
+	libDir := filepath.Join(dir, "lib")
+	session := runSemtype(assert, path, 0, "-dir", libDir, "-base-ref", "v1.2.0", "-head-ref", "v1.3.0")
+	assert.Expect(session.Out).To(gbytes.Say("1.3.0"))
+	assert.Expect(filepath.Join(libDir, "semtype.dat")).NotTo(BeAnExistingFile())
+
+	// without -head-ref, the working tree is compared against the base
+	session = runSemtype(assert, path, 0, "-dir", libDir, "-base-ref", "v1.3.0")
+	assert.Expect(session.Out).To(gbytes.Say("2.0.0"))
+
+	session = runSemtype(assert, path, 1, "-dir", libDir, "-base-ref", "missing")
+	assert.Expect(session.Err).To(gbytes.Say("loading -base-ref"))
+
+	session = runSemtype(assert, path, 1, "-dir", libDir, "-head-ref", "v1.3.0")
+	assert.Expect(session.Err).To(gbytes.Say("-head-ref requires -base-ref"))
+}
+
+func testOnly(t *testing.T, path string) {
+	assert := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	writeFiles(assert, dir, map[string]string{
+		"test.go": "package main\ntype Client struct{}\nfunc (c *Client) Do() {}\ntype Option func(*Client)\nfunc WithTimeout() Option { return nil }\nfunc Helper() {}\n",
+	})
+	session := runSemtype(assert, path, 0, "-dir", dir, "-only", "Client,With*")
+	assert.Expect(session.Out).To(gbytes.Say("0.1.0"))
+
+	// removing a symbol outside of the curated surface is not a change
+	writeFiles(assert, dir, map[string]string{
+		"test.go": "package main\ntype Client struct{}\nfunc (c *Client) Do() {}\ntype Option func(*Client)\nfunc WithTimeout() Option { return nil }\n",
+	})
+	session = runSemtype(assert, path, 0, "-dir", dir, "-only", "Client,With*")
+	assert.Expect(session.Out).To(gbytes.Say("0.1.1"))
+
+	// methods are part of their type
+	writeFiles(assert, dir, map[string]string{
+		"test.go": "package main\ntype Client struct{}\ntype Option func(*Client)\nfunc WithTimeout() Option { return nil }\n",
+	})
+	session = runSemtype(assert, path, 0, "-dir", dir, "-only", "Client,With*")
+	assert.Expect(session.Out).To(gbytes.Say("1.0.0"))
+	assert.Expect(session.Err).To(gbytes.Say("removed exported method Client.Do"))
+
+	session = runSemtype(assert, path, 1, "-dir", dir, "-only", "[")
+	assert.Expect(session.Err).To(gbytes.Say("parsing -only pattern"))
+}
+
+func testProfile(t *testing.T, path string) {
+	assert := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	writeFiles(assert, dir, map[string]string{"test.go": "package main\nfunc Exported() {}\n"})
+	cpuProfile, memProfile := filepath.Join(t.TempDir(), "cpu.pprof"), filepath.Join(t.TempDir(), "mem.pprof")
+	session := runSemtype(assert, path, 0, "-dir", dir, "-cpuprofile", cpuProfile, "-memprofile", memProfile)
+	assert.Expect(session.Out).To(gbytes.Say("0.1.0"))
+
+	for _, profile := range []string{cpuProfile, memProfile} {
+		info, err := os.Stat(profile)
+		assert.Expect(err).NotTo(HaveOccurred())
+		assert.Expect(info.Size()).To(BeNumerically(">", 0))
+	}
+}
+
+func testInterfaceToStruct(t *testing.T, path string) {
+	assert := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	writeFiles(assert, dir, map[string]string{"test.go": "package main\ntype Writer interface{ Write([]byte) (int, error) }\n"})
+	session := runSemtype(assert, path, 0, "-dir", dir)
+	assert.Expect(session.Out).To(gbytes.Say("0.1.0"))
+
+	writeFiles(assert, dir, map[string]string{"test.go": "package main\ntype Writer struct{ Buffer []byte }\n"})
+	session = runSemtype(assert, path, 0, "-dir", dir, "-explain")
+	assert.Expect(session.Out).To(gbytes.Say("1.0.0"))
+	assert.Expect(session.Err).To(gbytes.Say("Bumping 0.1.0 → 1.0.0 \\(major\\): changed exported type Writer: interface → struct: all implementers broken"))
+}
+
+func testFailOn(t *testing.T, path string) {
+	assert := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	writeFiles(assert, dir, map[string]string{"test.go": "package main\nfunc A() {}\n"})
+	session := runSemtype(assert, path, 0, "-dir", dir)
+	assert.Expect(session.Out).To(gbytes.Say("0.1.0"))
+
+	writeFiles(assert, dir, map[string]string{"test.go": "package main\nfunc A() {}\nfunc B() {}\n"})
+	session = runSemtype(assert, path, 0, "-dir", dir, "-fail-on", "major")
+	assert.Expect(session.Out).To(gbytes.Say("0.2.0"))
+
+	writeFiles(assert, dir, map[string]string{"test.go": "package main\nfunc B() {}\nfunc C() {}\n"})
+	session = runSemtype(assert, path, 1, "-dir", dir, "-fail-on", "major")
+	assert.Expect(session.Err).To(gbytes.Say("major: removed exported function A"))
+	assert.Expect(session.Err).To(gbytes.Say("major bump to 1.0.0 is not allowed by -fail-on major"))
+	assert.Expect(session.Err.Contents()).NotTo(ContainSubstring("minor: added exported function C"))
+
+	// the state is not updated by a failed run
+	session = runSemtype(assert, path, 0, "-dir", dir)
+	assert.Expect(session.Out).To(gbytes.Say("1.0.0"))
+}
+
+func testVersionFile(t *testing.T, path string) {
+	assert := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	writeFiles(assert, dir, map[string]string{
+		"test.go": "package main\nfunc A() {}\n",
+		"VERSION": "v2.1.0\n",
+	})
+	versionFile := filepath.Join(dir, "VERSION")
+
+	session := runSemtype(assert, path, 0, "-dir", dir, "-version-file", versionFile, "-initial", "1.0.0", "-dry-run")
+	assert.Expect(session.Out).To(gbytes.Say("1.1.0"))
+
+	session = runSemtype(assert, path, 0, "-dir", dir, "-version-file", versionFile)
+	assert.Expect(session.Out).To(gbytes.Say("2.2.0"))
+
+	// once there is a state file, its version is used
+	writeFiles(assert, dir, map[string]string{"VERSION": "5.0.0\n"})
+	session = runSemtype(assert, path, 0, "-dir", dir, "-version-file", versionFile)
+	assert.Expect(session.Out).To(gbytes.Say("2.2.1"))
+
+	session = runSemtype(assert, path, 1, "-dir", t.TempDir(), "-version-file", filepath.Join(dir, "MISSING"))
+	assert.Expect(session.Err).To(gbytes.Say("reading -version-file"))
+}
+
+func testPerPackage(t *testing.T, path string) {
+	assert := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	writeFiles(assert, dir, map[string]string{
+		"go.mod": "module example.com/mono\n\ngo 1.23\n",
+		"a/a.go": "package a\nfunc A() {}\n",
+		"b/b.go": "package b\nfunc B() {}\n",
+	})
+	session := runSemtype(assert, path, 0, "-dir", dir, "-recursive", "-per-package")
+	assert.Expect(session.Out).To(gbytes.Say(`{"package":"example.com/mono/a","version":"0.1.0","bump":"minor"}`))
+	assert.Expect(session.Out).To(gbytes.Say(`{"package":"example.com/mono/b","version":"0.1.0","bump":"minor"}`))
+
+	writeFiles(assert, dir, map[string]string{"b/b.go": "package b\nfunc B() {}\nfunc C() {}\n"})
+	session = runSemtype(assert, path, 0, "-dir", dir, "-recursive", "-per-package")
+	assert.Expect(session.Out).To(gbytes.Say(`{"package":"example.com/mono/a","version":"0.1.0"}`))
+	assert.Expect(session.Out).To(gbytes.Say(`{"package":"example.com/mono/b","version":"0.2.0","bump":"minor"}`))
+
+	session = runSemtype(assert, path, 1, "-dir", dir, "-per-package")
+	assert.Expect(session.Err).To(gbytes.Say("-per-package requires -recursive"))
+
+	session = runSemtype(assert, path, 1, "-dir", dir, "-recursive", "-per-package", "-bump-only", "-only", "B")
+	assert.Expect(session.Err).To(gbytes.Say("-per-package cannot be combined with -bump-only, -only"))
+
+	// A package bumping beyond -fail-on fails the run without saving the state
+	writeFiles(assert, dir, map[string]string{"b/b.go": "package b\nfunc B() {}\n"})
+	session = runSemtype(assert, path, 1, "-dir", dir, "-recursive", "-per-package", "-fail-on", "major")
+	assert.Expect(session.Err).To(gbytes.Say("major: removed exported function C"))
+	assert.Expect(session.Err).To(gbytes.Say("bumps of example.com/mono/b are not allowed by -fail-on major"))
+	assert.Expect(session.Out).NotTo(gbytes.Say("version"))
+
+	session = runSemtype(assert, path, 0, "-dir", dir, "-recursive", "-per-package")
+	assert.Expect(session.Out).To(gbytes.Say(`{"package":"example.com/mono/b","version":"1.0.0","bump":"major"}`))
+
+	// Unchanged packages add no snapshots to their history
+	for range 3 {
+		runSemtype(assert, path, 0, "-dir", dir, "-recursive", "-per-package", "-state-format", "json")
+	}
+	contents, err := os.ReadFile(filepath.Join(dir, "semtype.dat"))
+	assert.Expect(err).NotTo(HaveOccurred())
+	var state struct {
+		Packages map[string]struct{ History []any }
+	}
+	assert.Expect(json.Unmarshal(contents, &state)).To(Succeed())
+	assert.Expect(state.Packages["example.com/mono/a"].History).To(BeEmpty())
+	assert.Expect(state.Packages["example.com/mono/b"].History).To(HaveLen(2))
+}
+
+func writeFiles(assert *WithT, dir string, files map[string]string) {
+	for filename, contents := range files {
+		fullPath := filepath.Join(dir, filename)
+
+		err := os.MkdirAll(filepath.Dir(fullPath), os.ModePerm)
+		assert.Expect(err).NotTo(HaveOccurred())
+
+		err = os.WriteFile(fullPath, []byte(contents), 0644)
+		assert.Expect(err).NotTo(HaveOccurred())
+	}
+}
+
+func runSemtype(assert *WithT, path string, exitCode int, args ...string) *gexec.Session {
+	session, err := gexec.Start(exec.Command(path, args...), gbytes.NewBuffer(), gbytes.NewBuffer())
+	assert.Expect(err).NotTo(HaveOccurred())
+	assert.Eventually(session).Should(gexec.Exit(exitCode), func() string {
+		return fmt.Sprintf("stdout: %s\nstderr: %s", session.Out.Contents(), session.Err.Contents())
+	})
+
+	return session
+}
+
+func testUnparseableFiles(t *testing.T, path string) {
+	files := map[string]string{
+		"good.go":   "package main\nfunc Exported() {}\n",
+		"broken.go": "package main\nfunc Broken( {\n",
+	}
+
+	t.Run("skips broken files with a warning", func(t *testing.T) {
+		assert := NewGomegaWithT(t)
+
+		dir := t.TempDir()
+		writeFiles(assert, dir, files)
+
+		session := runSemtype(assert, path, 0, "-dir", dir)
+		assert.Expect(session.Out).To(gbytes.Say("0.1.0"))
+		assert.Expect(session.Err).To(gbytes.Say("skipping file that failed to parse"))
+		assert.Expect(session.Err).To(gbytes.Say("broken.go"))
+	})
+
+	t.Run("fails with -strict-parse", func(t *testing.T) {
+		assert := NewGomegaWithT(t)
+
+		dir := t.TempDir()
+		writeFiles(assert, dir, files)
+
+		session := runSemtype(assert, path, 1, "-dir", dir, "-strict-parse")
+		assert.Expect(session.Err).To(gbytes.Say("broken.go"))
+		assert.Expect(filepath.Join(dir, "semtype.dat")).NotTo(BeAnExistingFile())
+	})
+}
+
+func testCheckModulePath(t *testing.T, path string) {
+	// reach 1.0.0 by adding then removing a function, then remove another
+	// function with the module path check enabled to require a /v2 suffix
+	prepare := func(assert *WithT, modulePath string) string {
+		dir := t.TempDir()
+		writeFiles(assert, dir, map[string]string{
+			"go.mod":  "module " + modulePath + "\n\ngo 1.23\n",
+			"test.go": "package lib\nfunc A() {}\nfunc B() {}\n",
+		})
+		session := runSemtype(assert, path, 0, "-dir", dir)
+		assert.Expect(session.Out).To(gbytes.Say("0.1.0"))
+
+		writeFiles(assert, dir, map[string]string{"test.go": "package lib\nfunc B() {}\n"})
+		session = runSemtype(assert, path, 0, "-dir", dir)
+		assert.Expect(session.Out).To(gbytes.Say("1.0.0"))
+
+		writeFiles(assert, dir, map[string]string{"test.go": "package lib\n"})
+		return dir
+	}
+
+	t.Run("major bump with a matching suffix", func(t *testing.T) {
+		assert := NewGomegaWithT(t)
+
+		dir := prepare(assert, "example.com/lib/v2")
+		session := runSemtype(assert, path, 0, "-dir", dir, "-check-module-path")
+		assert.Expect(session.Out).To(gbytes.Say("2.0.0"))
+	})
+
+	t.Run("major bump with a missing suffix", func(t *testing.T) {
+		assert := NewGomegaWithT(t)
+
+		dir := prepare(assert, "example.com/lib")
+		session := runSemtype(assert, path, 1, "-dir", dir, "-check-module-path")
+		assert.Expect(session.Err).To(gbytes.Say(`requires suffix \\"/v2\\" for version 2.0.0`))
+
+		session = runSemtype(assert, path, 0, "-dir", dir)
+		assert.Expect(session.Out).To(gbytes.Say("2.0.0"))
+	})
+}
+
+func testAliasedImportsAcrossFiles(t *testing.T, path string) {
+	assert := NewGomegaWithT(t)
+
+	// Interfaces, constraints, and methods declared with an aliased import are
+	// expanded into the signatures of another file
+	dir := t.TempDir()
+	writeFiles(assert, dir, map[string]string{
+		"a.go": "package main\ntype RW interface{ R; Close() error }\nfunc Write[T Writer](value T) {}\ntype Buffer struct{ Inner }\n",
+		"b.go": "package main\nimport stdio \"io\"\ntype R interface{ Read(w stdio.Writer) error }\ntype Writer interface{ WriteTo(w stdio.Writer) }\ntype Inner struct{}\nfunc (Inner) Copy(w stdio.Writer) {}\n",
+	})
+
+	session := runSemtype(assert, path, 0, "-dir", dir)
+	assert.Expect(session.Out).To(gbytes.Say("0.1.0"))
+	for i := 1; i <= 10; i++ {
+		session := runSemtype(assert, path, 0, "-dir", dir)
+		assert.Expect(session.Out).To(gbytes.Say(fmt.Sprintf("0.1.%d", i)))
+		assert.Expect(session.Err).NotTo(gbytes.Say("detected change"))
+	}
+}
+
+func testImportAliasOfPackageName(t *testing.T, path string) {
+	assert := NewGomegaWithT(t)
+
+	// The alias is replaced with the name the package declares, rather than
+	// one guessed from its directory
+	dir := t.TempDir()
+	writeFiles(assert, dir, map[string]string{
+		"go.mod":             "module example.com/m\n\ngo 1.21\n",
+		"main.go":            "package main\nimport \"example.com/m/lib/go-util\"\nfunc Load() util2.Config { return util2.Config{} }\n",
+		"lib/go-util/lib.go": "package util2\ntype Config struct{}\n",
+	})
+
+	session := runSemtype(assert, path, 0, "-dir", dir)
+	assert.Expect(session.Out).To(gbytes.Say("0.1.0"))
+
+	writeFiles(assert, dir, map[string]string{
+		"main.go": "package main\nimport u \"example.com/m/lib/go-util\"\nfunc Load() u.Config { return u.Config{} }\n",
+	})
+
+	session = runSemtype(assert, path, 0, "-dir", dir)
+	assert.Expect(session.Out).To(gbytes.Say("0.1.1"))
+	assert.Expect(session.Err).NotTo(gbytes.Say("detected change"))
 }
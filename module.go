@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// findModule returns the module path declared by the go.mod file in dir or
+// its closest parent directory, along with the directory containing it.
+func findModule(dir string) (modulePath string, moduleRoot string, err error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", "", fmt.Errorf("resolving directory: %w", err)
+	}
+
+	for {
+		modulePath, err := readModulePath(filepath.Join(absDir, "go.mod"))
+		if err == nil {
+			return modulePath, absDir, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", "", err
+		}
+
+		parent := filepath.Dir(absDir)
+		if parent == absDir {
+			return "", "", fmt.Errorf("no go.mod found for %s", dir)
+		}
+		absDir = parent
+	}
+}
+
+func readModulePath(goModFile string) (string, error) {
+	file, err := os.Open(goModFile)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil {
+			slog.Warn("failed to close go.mod", "error", closeErr)
+		}
+	}()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if comment := strings.Index(line, "//"); comment >= 0 {
+			line = strings.TrimSpace(line[:comment])
+		}
+
+		rest, ok := strings.CutPrefix(line, "module")
+		if !ok || rest == "" || (rest[0] != ' ' && rest[0] != '\t') {
+			continue
+		}
+
+		modulePath := strings.TrimSpace(rest)
+		if unquoted, err := strconv.Unquote(modulePath); err == nil {
+			modulePath = unquoted
+		}
+		return modulePath, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("reading %s: %w", goModFile, err)
+	}
+
+	return "", fmt.Errorf("no module directive in %s", goModFile)
+}
+
+// checkModulePath verifies the module path has the major version suffix
+// required by the version, following the Go module conventions.
+func checkModulePath(modulePath string, version Version) error {
+	expected := ""
+	if version.Major >= 2 {
+		expected = fmt.Sprintf("/v%d", version.Major)
+	}
+
+	actual := ""
+	if index := strings.LastIndex(modulePath, "/"); index >= 0 {
+		if major, ok := strings.CutPrefix(modulePath[index+1:], "v"); ok {
+			if n, err := strconv.Atoi(major); err == nil && n >= 2 {
+				actual = "/v" + major
+			}
+		}
+	}
+
+	if actual == expected {
+		return nil
+	}
+	if expected == "" {
+		return fmt.Errorf("module path %q has suffix %q but version %s requires none", modulePath, actual, version)
+	}
+	return fmt.Errorf("module path %q requires suffix %q for version %s", modulePath, expected, version)
+}
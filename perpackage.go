@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// packageRelease is the version decision for a single package, printed as a
+// line of JSON by -per-package. Bump is empty when the package is unchanged.
+type packageRelease struct {
+	Package string `json:"package"`
+	Version string `json:"version"`
+	Bump    string `json:"bump,omitempty"`
+}
+
+// releasePerPackage versions every package in the tree on its own, as done by
+// monorepos that release their packages independently. A package without
+// changes keeps its version, so that it is not released again, and packages
+// that no longer exist are dropped from the state. With -fail-on, the state
+// is only saved when no package bumps beyond it.
+func releasePerPackage(config *config, options analyzeOptions) error {
+	previousState, err := loadState(config.stateFile, config.initial)
+	if err != nil {
+		return fmt.Errorf("loading state: %w", err)
+	}
+
+	packages, err := packageDirs(config.dir, options)
+	if err != nil {
+		return fmt.Errorf("analyzing packages: %w", err)
+	}
+	results, err := analyzeEach(packages, options)
+	if err != nil {
+		return fmt.Errorf("analyzing packages: %w", err)
+	}
+
+	importPrefix := importPathPrefix(config.dir)
+	newState := State{Version: previousState.Version, Exported: newExported(), Packages: make(map[string]State)}
+	var releases []packageRelease
+	var failures []string
+	for i, pkg := range packages {
+		currentExported := results[i]
+		if !currentExported.Packages[""] {
+			continue
+		}
+
+		importPath := path.Join(importPrefix, strings.TrimSuffix(pkg.prefix, "."))
+		previous, ok := previousState.Packages[importPath]
+		if !ok {
+			previous = State{Version: parseVersion(config.initial).String(), Exported: newExported()}
+		}
+
+		if !config.positions {
+			currentExported.Positions = make(map[string]token.Position)
+		}
+
+		changes := Diff(previous.Exported, currentExported)
+		if config.smartWiden {
+			changes = widenParams(currentExported, changes)
+		}
+		for _, change := range changes {
+			slog.Info("detected change", "package", importPath, "kind", change.Kind, "symbol", change.Symbol, "bump", change.Bump.String(), "reason", change.Reason)
+		}
+
+		newVersion, bump := parseVersion(previous.Version), ""
+		// An unchanged package keeps its state, without another snapshot of
+		// the same version in its history
+		history := previous.History
+		if !ok || len(changes) > 0 || config.minBump > Patch {
+			newVersion = calculateVersion(previous, changes, config.minBump)
+			bump = versionBump(parseVersion(previous.Version), newVersion).String()
+			history = previous.nextHistory(config.maxHistory)
+
+			if config.failOn != nil && versionBump(parseVersion(previous.Version), newVersion) >= *config.failOn {
+				for _, change := range changes {
+					if change.Bump >= *config.failOn {
+						fmt.Fprintf(os.Stderr, "%s: %s: %s\n", importPath, change.Bump, change.Reason)
+					}
+				}
+				failures = append(failures, importPath)
+			}
+		}
+		if !config.fullSignatures {
+			currentExported = hashSignatures(currentExported)
+		}
+		newState.Packages[importPath] = State{
+			Version:  newVersion.String(),
+			Exported: currentExported,
+			History:  history,
+		}
+
+		releases = append(releases, packageRelease{
+			Package: importPath,
+			Version: config.prefix + newVersion.String(),
+			Bump:    bump,
+		})
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("bumps of %s are not allowed by -fail-on %s", strings.Join(failures, ", "), *config.failOn)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	for _, release := range releases {
+		if err := encoder.Encode(release); err != nil {
+			return fmt.Errorf("printing version of %s: %w", release.Package, err)
+		}
+	}
+
+	if !config.dryRun {
+		if err := saveState(config.stateFile, newState, config.stateFormat, config.compress); err != nil {
+			return fmt.Errorf("saving state: %w", err)
+		}
+	}
+	return nil
+}
+
+// importPathPrefix returns the import path of the package in dir, or "." when
+// it is not part of a module, so that nested packages are keyed by directory.
+func importPathPrefix(dir string) string {
+	modulePath, moduleRoot, err := findModule(dir)
+	if err != nil {
+		slog.Warn("keying packages by directory", "error", err)
+		return "."
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "."
+	}
+	rel, err := filepath.Rel(moduleRoot, absDir)
+	if err != nil {
+		return "."
+	}
+	return path.Join(modulePath, filepath.ToSlash(rel))
+}
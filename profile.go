@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+// startProfiling starts writing a CPU profile to cpuProfile, when given. The
+// returned function stops it, and writes a heap profile to memProfile, when
+// given, once the work to profile is done.
+func startProfiling(cpuProfile, memProfile string) (func() error, error) {
+	var cpuFile *os.File
+	if cpuProfile != "" {
+		var err error
+		cpuFile, err = os.Create(cpuProfile)
+		if err != nil {
+			return nil, fmt.Errorf("creating CPU profile: %w", err)
+		}
+		if err := pprof.StartCPUProfile(cpuFile); err != nil {
+			_ = cpuFile.Close()
+			return nil, fmt.Errorf("starting CPU profile: %w", err)
+		}
+	}
+
+	return func() error {
+		var errs []error
+		if cpuFile != nil {
+			pprof.StopCPUProfile()
+			if err := cpuFile.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("closing CPU profile: %w", err))
+			}
+		}
+
+		if memProfile != "" {
+			errs = append(errs, writeHeapProfile(memProfile))
+		}
+		return errors.Join(errs...)
+	}, nil
+}
+
+func writeHeapProfile(memProfile string) error {
+	file, err := os.Create(memProfile)
+	if err != nil {
+		return fmt.Errorf("creating memory profile: %w", err)
+	}
+
+	// Only live objects are of interest
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(file); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("writing memory profile: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("closing memory profile: %w", err)
+	}
+	return nil
+}
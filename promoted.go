@@ -0,0 +1,205 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// promoter finds the methods that exported struct types get from the types
+// they embed, which are part of their API just like declared methods.
+type promoter struct {
+	structs    map[string]*ast.StructType
+	interfaces interfaceSet
+	methods    map[string][]*ast.FuncDecl
+}
+
+func newPromoter(files map[string]*ast.File, interfaces interfaceSet) *promoter {
+	p := &promoter{
+		structs:    make(map[string]*ast.StructType),
+		interfaces: interfaces,
+		methods:    make(map[string][]*ast.FuncDecl),
+	}
+
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			switch d := decl.(type) {
+			case *ast.GenDecl:
+				if d.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range d.Specs {
+					if typeSpec, ok := spec.(*ast.TypeSpec); ok {
+						if structType, ok := typeSpec.Type.(*ast.StructType); ok {
+							p.structs[typeSpec.Name.Name] = structType
+						}
+					}
+				}
+			case *ast.FuncDecl:
+				if d.Recv != nil && d.Name.IsExported() {
+					receiver := receiverTypeName(d.Recv)
+					p.methods[receiver] = append(p.methods[receiver], d)
+				}
+			}
+		}
+	}
+
+	return p
+}
+
+// embed is a type embedded in a struct, directly or through other embeds.
+// addressable is set when the path to it goes through a pointer, so that even
+// its pointer receiver methods are promoted to the value of the outer type.
+type embed struct {
+	typeName    string
+	addressable bool
+}
+
+// promotion is a method or field found at some depth of embedding
+type promotion struct {
+	funcType    *ast.FuncType
+	valueMethod bool
+	fieldType   ast.Expr
+}
+
+// promotions returns the type of the method expression of every method, and
+// the type of every field, promoted to an exported struct type, keyed like
+// declared methods. Members of types from other packages are not known, so
+// they are not included.
+func (p *promoter) promotions() (map[string]*ast.FuncType, map[string]ast.Expr) {
+	methods := make(map[string]*ast.FuncType)
+	fields := make(map[string]ast.Expr)
+	for outer, structType := range p.structs {
+		if !ast.IsExported(outer) {
+			continue
+		}
+
+		for name, promoted := range p.promote(outer, structType) {
+			if !ast.IsExported(name) {
+				continue
+			}
+			if promoted.fieldType != nil {
+				fields[outer+"."+name] = stripFuncNames(promoted.fieldType)
+				continue
+			}
+
+			var receiver ast.Expr = ast.NewIdent(outer)
+			if !promoted.valueMethod {
+				receiver = &ast.StarExpr{X: receiver}
+			}
+			methods[outer+"."+name] = methodExprType(receiver, stripParamNames(promoted.funcType))
+		}
+	}
+	return methods, fields
+}
+
+// promote walks the embedded types breadth first, as a shallower method or
+// field hides deeper ones, and members found twice at the same depth are
+// ambiguous and not promoted at all.
+func (p *promoter) promote(outer string, structType *ast.StructType) map[string]promotion {
+	hidden := map[string]bool{}
+	for _, method := range p.methods[outer] {
+		hidden[method.Name.Name] = true
+	}
+	level, fields := p.embeds(structType, false)
+	for _, field := range fields {
+		hidden[field.name] = true
+	}
+
+	promoted := make(map[string]promotion)
+	// Types walked at a shallower depth are skipped, which ends cycles, but a
+	// type reached twice at the same depth is walked twice, making its
+	// members ambiguous
+	seen := map[string]bool{outer: true}
+	for len(level) > 0 {
+		found := make(map[string][]promotion)
+		var next []embed
+		for _, e := range level {
+			if seen[e.typeName] {
+				continue
+			}
+
+			for _, method := range p.methods[e.typeName] {
+				found[method.Name.Name] = append(found[method.Name.Name], promotion{
+					funcType:    method.Type,
+					valueMethod: e.addressable || !isPointerReceiver(method.Recv.List[0].Type),
+				})
+			}
+			if interfaceType, ok := p.interfaces[e.typeName]; ok {
+				for _, field := range p.interfaces.expand(interfaceType).Methods.List {
+					funcType, ok := field.Type.(*ast.FuncType)
+					if !ok {
+						continue
+					}
+					for _, name := range field.Names {
+						found[name.Name] = append(found[name.Name], promotion{funcType: funcType, valueMethod: true})
+					}
+				}
+			}
+			if embedded, ok := p.structs[e.typeName]; ok {
+				embeds, fields := p.embeds(embedded, e.addressable)
+				for _, field := range fields {
+					found[field.name] = append(found[field.name], promotion{fieldType: field.typ})
+				}
+				next = append(next, embeds...)
+			}
+		}
+
+		for _, e := range level {
+			seen[e.typeName] = true
+		}
+
+		for name, promotions := range found {
+			if hidden[name] {
+				continue
+			}
+			hidden[name] = true
+			if len(promotions) == 1 {
+				promoted[name] = promotions[0]
+			}
+		}
+		level = next
+	}
+
+	return promoted
+}
+
+// member is a field of a struct, named or embedded
+type member struct {
+	name string
+	typ  ast.Expr
+}
+
+// embeds returns the local types embedded in the struct, and all its fields,
+// including the embedded ones, which are named after their type.
+func (p *promoter) embeds(structType *ast.StructType, addressable bool) ([]embed, []member) {
+	var embeds []embed
+	var fields []member
+	for _, field := range structType.Fields.List {
+		for _, name := range field.Names {
+			fields = append(fields, member{name: name.Name, typ: field.Type})
+		}
+		if len(field.Names) > 0 {
+			continue
+		}
+
+		fields = append(fields, member{name: embeddedName(field.Type), typ: field.Type})
+		typeName := baseTypeName(field.Type)
+		if typeName == "" {
+			continue
+		}
+		embeds = append(embeds, embed{typeName: typeName, addressable: addressable || isPointerReceiver(field.Type)})
+	}
+	return embeds, fields
+}
+
+// embeddedName returns the name of an embedded field, which is the name of its
+// type without the package or type arguments.
+func embeddedName(expr ast.Expr) string {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	if selector, ok := expr.(*ast.SelectorExpr); ok {
+		return selector.Sel.Name
+	}
+	return baseTypeName(expr)
+}
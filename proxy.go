@@ -0,0 +1,231 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// defaultProxy is used when GOPROXY does not name a usable proxy
+const defaultProxy = "https://proxy.golang.org"
+
+// errNotPublished is returned when the module proxy has no release of a module
+var errNotPublished = errors.New("module has no published version")
+
+// loadProxyBaseline builds the previous state from the latest version of the
+// module containing dir, as published on the module proxy.
+func loadProxyBaseline(dir string, roots []string, options analyzeOptions) (State, error) {
+	modulePath, moduleRoot, err := findModule(dir)
+	if err != nil {
+		return State{}, fmt.Errorf("finding module: %w", err)
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return State{}, fmt.Errorf("resolving directory: %w", err)
+	}
+	packageDir, err := filepath.Rel(moduleRoot, absDir)
+	if err != nil {
+		return State{}, fmt.Errorf("resolving package directory: %w", err)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	proxy, err := moduleProxy(os.Getenv("GOPROXY"))
+	if err != nil {
+		return State{}, err
+	}
+
+	version, err := fetchLatestVersion(client, proxy, modulePath)
+	if errors.Is(err, errNotPublished) {
+		slog.Warn("no published version on module proxy, using empty baseline", "module", modulePath)
+		return State{Version: "0.0.0", Exported: newExported()}, nil
+	}
+	if err != nil {
+		return State{}, err
+	}
+
+	sourceDir, err := os.MkdirTemp("", "semtype-proxy-")
+	if err != nil {
+		return State{}, fmt.Errorf("creating temporary directory: %w", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(sourceDir); removeErr != nil {
+			slog.Warn("failed to remove temporary directory", "dir", sourceDir, "error", removeErr)
+		}
+	}()
+
+	if err := fetchModuleZip(client, proxy, modulePath, version, sourceDir); err != nil {
+		return State{}, err
+	}
+
+	exported, err := analyzeRoots(filepath.Join(sourceDir, modulePath+"@"+version, packageDir), roots, options)
+	if err != nil {
+		return State{}, fmt.Errorf("analyzing %s@%s: %w", modulePath, version, err)
+	}
+
+	return State{Version: strings.TrimPrefix(version, "v"), Exported: exported}, nil
+}
+
+// moduleProxy returns the first proxy URL listed in goproxy, the value of
+// GOPROXY, or the default proxy when it is unset. Fetching directly from
+// version control is not supported, so direct entries are skipped, and off
+// disables every entry after it, as it does for the go command.
+func moduleProxy(goproxy string) (string, error) {
+	if goproxy == "" {
+		return defaultProxy, nil
+	}
+
+	for _, entry := range strings.FieldsFunc(goproxy, func(r rune) bool {
+		return r == ',' || r == '|'
+	}) {
+		switch entry = strings.TrimSpace(entry); entry {
+		case "", "direct":
+			continue
+		case "off":
+			return "", fmt.Errorf("GOPROXY=%s disables the module proxy", goproxy)
+		}
+		return strings.TrimSuffix(entry, "/"), nil
+	}
+	return "", fmt.Errorf("GOPROXY=%s lists no module proxy", goproxy)
+}
+
+func fetchLatestVersion(client *http.Client, proxy, modulePath string) (string, error) {
+	body, err := fetchFromProxy(client, proxy+"/"+escapeModulePath(modulePath)+"/@latest")
+	if err != nil {
+		return "", err
+	}
+	defer closeBody(body)
+
+	var info struct {
+		Version string
+	}
+	if err := json.NewDecoder(body).Decode(&info); err != nil {
+		return "", fmt.Errorf("decoding latest version of %s: %w", modulePath, err)
+	}
+	if info.Version == "" {
+		return "", errNotPublished
+	}
+
+	return info.Version, nil
+}
+
+// fetchModuleZip downloads and extracts the module zip into dir, which then
+// contains a single "module@version" directory.
+func fetchModuleZip(client *http.Client, proxy, modulePath, version, dir string) error {
+	body, err := fetchFromProxy(client, proxy+"/"+escapeModulePath(modulePath)+"/@v/"+version+".zip")
+	if err != nil {
+		return err
+	}
+	defer closeBody(body)
+
+	archive, err := os.CreateTemp(dir, "*.zip")
+	if err != nil {
+		return fmt.Errorf("creating module zip: %w", err)
+	}
+	defer func() {
+		if closeErr := archive.Close(); closeErr != nil {
+			slog.Warn("failed to close module zip", "error", closeErr)
+		}
+	}()
+
+	size, err := io.Copy(archive, body)
+	if err != nil {
+		return fmt.Errorf("downloading %s@%s: %w", modulePath, version, err)
+	}
+
+	reader, err := zip.NewReader(archive, size)
+	if err != nil {
+		return fmt.Errorf("reading module zip: %w", err)
+	}
+
+	for _, file := range reader.File {
+		// Only Go sources are needed, and paths must stay inside dir
+		if file.FileInfo().IsDir() || filepath.Ext(file.Name) != ".go" {
+			continue
+		}
+		target := filepath.Join(dir, filepath.FromSlash(file.Name))
+		if !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("module zip contains invalid path %q", file.Name)
+		}
+
+		if err := extractZipFile(file, target); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func extractZipFile(file *zip.File, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+		return fmt.Errorf("creating directory for %s: %w", file.Name, err)
+	}
+
+	source, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", file.Name, err)
+	}
+	defer func() {
+		if closeErr := source.Close(); closeErr != nil {
+			slog.Warn("failed to close zip entry", "name", file.Name, "error", closeErr)
+		}
+	}()
+
+	contents, err := io.ReadAll(source)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", file.Name, err)
+	}
+
+	if err := os.WriteFile(target, contents, 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", file.Name, err)
+	}
+
+	return nil
+}
+
+func fetchFromProxy(client *http.Client, url string) (io.ReadCloser, error) {
+	response, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("requesting %s: %w", url, err)
+	}
+
+	switch response.StatusCode {
+	case http.StatusOK:
+		return response.Body, nil
+	case http.StatusNotFound, http.StatusGone:
+		closeBody(response.Body)
+		return nil, errNotPublished
+	default:
+		closeBody(response.Body)
+		return nil, fmt.Errorf("requesting %s: unexpected status %s", url, response.Status)
+	}
+}
+
+func closeBody(body io.ReadCloser) {
+	if err := body.Close(); err != nil {
+		slog.Warn("failed to close response body", "error", err)
+	}
+}
+
+// escapeModulePath encodes upper case letters as the module proxy protocol
+// requires, e.g. "github.com/Azure" becomes "github.com/!azure".
+func escapeModulePath(modulePath string) string {
+	var builder strings.Builder
+	for _, r := range modulePath {
+		if unicode.IsUpper(r) {
+			builder.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
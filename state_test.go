@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestSaveState(t *testing.T) {
+	t.Parallel()
+
+	t.Run("an interrupted write keeps the previous state", func(t *testing.T) {
+		assert := NewGomegaWithT(t)
+
+		dir := t.TempDir()
+		stateFile := filepath.Join(dir, "semtype.dat")
+		previous := State{Version: "1.2.3", Exported: newExported()}
+		previous.Exported.Functions["Exported"] = "func()"
+		assert.Expect(saveState(stateFile, previous, "gob", false)).To(Succeed())
+
+		interrupted := errors.New("interrupted")
+		err := writeFileAtomic(stateFile, func(w io.Writer) error {
+			if _, err := w.Write([]byte("partial")); err != nil {
+				return err
+			}
+			return interrupted
+		})
+		assert.Expect(err).To(MatchError(interrupted))
+
+		state, err := loadState(stateFile, "0.0.0")
+		assert.Expect(err).NotTo(HaveOccurred())
+		assert.Expect(state.Version).To(Equal("1.2.3"))
+		assert.Expect(state.Exported.Functions).To(HaveKeyWithValue("Exported", "func()"))
+
+		entries, err := os.ReadDir(dir)
+		assert.Expect(err).NotTo(HaveOccurred())
+		assert.Expect(entries).To(HaveLen(1))
+	})
+
+	t.Run("replaces the previous state", func(t *testing.T) {
+		assert := NewGomegaWithT(t)
+
+		stateFile := filepath.Join(t.TempDir(), "semtype.dat")
+		assert.Expect(saveState(stateFile, State{Version: "1.0.0", Exported: newExported()}, "gob", false)).To(Succeed())
+		assert.Expect(saveState(stateFile, State{Version: "2.0.0", Exported: newExported()}, "gob", false)).To(Succeed())
+
+		state, err := loadState(stateFile, "0.0.0")
+		assert.Expect(err).NotTo(HaveOccurred())
+		assert.Expect(state.Version).To(Equal("2.0.0"))
+
+		info, err := os.Stat(stateFile)
+		assert.Expect(err).NotTo(HaveOccurred())
+		assert.Expect(info.Mode().Perm()).To(Equal(os.FileMode(0o644)))
+	})
+
+	t.Run("round trips a compressed state", func(t *testing.T) {
+		assert := NewGomegaWithT(t)
+
+		state := State{Version: "3.1.4", Exported: newExported()}
+		for i := range 5000 {
+			state.Exported.Functions[fmt.Sprintf("Function%d", i)] = "func(context.Context, string) (*Response, error)"
+			state.Exported.Types[fmt.Sprintf("Type%d", i)] = "struct {\n\tName string\n\tSize int\n}"
+		}
+
+		dir := t.TempDir()
+		plainFile := filepath.Join(dir, "plain.dat")
+		compressedFile := filepath.Join(dir, "compressed.dat")
+		assert.Expect(saveState(plainFile, state, "gob", false)).To(Succeed())
+		assert.Expect(saveState(compressedFile, state, "gob", true)).To(Succeed())
+
+		contents, err := os.ReadFile(compressedFile)
+		assert.Expect(err).NotTo(HaveOccurred())
+		assert.Expect(contents[:2]).To(Equal(gzipMagic))
+		plainInfo, err := os.Stat(plainFile)
+		assert.Expect(err).NotTo(HaveOccurred())
+		assert.Expect(len(contents)).To(BeNumerically("<", plainInfo.Size()/4))
+
+		for _, stateFile := range []string{plainFile, compressedFile} {
+			loaded, err := loadState(stateFile, "0.0.0")
+			assert.Expect(err).NotTo(HaveOccurred())
+			assert.Expect(loaded).To(Equal(state))
+		}
+	})
+
+	t.Run("round trips every state format", func(t *testing.T) {
+		state := State{Version: "2.0.0", Exported: newExported()}
+		state.Exported.Functions["Parse"] = "func(string) (int, error)"
+		state.Exported.Deprecated["Parse"] = true
+		state.Exported.Implements["MemStore"] = []string{"Store"}
+		state.History = []Snapshot{{Version: "1.0.0", Exported: newExported()}}
+
+		for _, format := range stateFormats {
+			t.Run(format, func(t *testing.T) {
+				assert := NewGomegaWithT(t)
+
+				stateFile := filepath.Join(t.TempDir(), "semtype.dat")
+				assert.Expect(saveState(stateFile, state, format, false)).To(Succeed())
+
+				loaded, err := loadState(stateFile, "0.0.0")
+				assert.Expect(err).NotTo(HaveOccurred())
+				assert.Expect(loaded.Version).To(Equal(state.Version))
+				assert.Expect(loaded.Exported.Functions).To(Equal(state.Exported.Functions))
+				assert.Expect(loaded.Exported.Deprecated).To(Equal(state.Exported.Deprecated))
+				assert.Expect(loaded.Exported.Implements).To(Equal(state.Exported.Implements))
+				assert.Expect(loaded.History).To(HaveLen(1))
+				assert.Expect(loaded.History[0].Version).To(Equal("1.0.0"))
+			})
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// stateFormats are the encodings of the state file, gob being the most
+// compact, and JSON or YAML reviewable in diffs
+var stateFormats = []string{"gob", "json", "yaml"}
+
+// encodeState writes the state in one of stateFormats
+func encodeState(w io.Writer, state State, format string) error {
+	switch format {
+	case "gob":
+		return gob.NewEncoder(w).Encode(&state)
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(&state)
+	case "yaml":
+		encoder := yaml.NewEncoder(w)
+		if err := encoder.Encode(&state); err != nil {
+			return err
+		}
+		return encoder.Close()
+	}
+	return fmt.Errorf("unknown state format %q, expected gob, json, or yaml", format)
+}
+
+// decodeState reads a state in any of stateFormats, trying each in turn, so
+// that a state file written in another format than the configured one still
+// loads. The error is the one of the gob decoder, the default format.
+func decodeState(contents []byte) (State, error) {
+	var errs []error
+	for _, format := range stateFormats {
+		var state State
+		var err error
+		switch format {
+		case "gob":
+			err = gob.NewDecoder(bytes.NewReader(contents)).Decode(&state)
+		case "json":
+			err = json.Unmarshal(contents, &state)
+		case "yaml":
+			err = yaml.Unmarshal(contents, &state)
+			// Any text is valid YAML, but not a state without a version
+			if err == nil && state.Version == "" {
+				err = errors.New("no version in YAML state")
+			}
+		}
+		if err == nil {
+			return state, nil
+		}
+		errs = append(errs, err)
+	}
+	return State{}, errs[0]
+}
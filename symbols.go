@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// listSymbols prints every exported symbol of the current source, with its
+// kind and signature, without reading or writing the state file.
+func listSymbols(config *config, options analyzeOptions) error {
+	var exported Exported
+	var err error
+	if config.file != "" {
+		exported, err = analyzeFile(config.file, options)
+	} else if len(config.dirs) > 0 {
+		exported, err = analyzeDirList(config.dirs, options)
+	} else {
+		exported, err = analyzeRoots(config.dir, config.roots, options)
+	}
+	if errors.Is(err, ErrNoSources) {
+		slog.Warn("no Go files to analyze", "error", err)
+	} else if err != nil {
+		return fmt.Errorf("analyzing package: %w", err)
+	}
+
+	if len(config.only) > 0 {
+		exported = exported.only(config.only)
+	}
+	fmt.Print(formatSymbols(exported))
+	return nil
+}
+
+// formatSymbols lists the symbols one per line, grouped by kind in the order
+// they are compared, and sorted by name within each kind.
+func formatSymbols(exported Exported) string {
+	var builder strings.Builder
+	for _, symbols := range []struct {
+		kind       string
+		signatures map[string]string
+	}{
+		{"type", exported.Types},
+		{"function", exported.Functions},
+		{"method", exported.Methods},
+		{"constant", exported.Constants},
+		{"variable", exported.Variables},
+	} {
+		for _, name := range sortedKeys(symbols.signatures) {
+			signature := symbols.signatures[name]
+			if symbols.kind == "type" {
+				name += exported.TypeParams[name]
+			}
+			if signature == "" {
+				fmt.Fprintf(&builder, "%s %s\n", symbols.kind, name)
+				continue
+			}
+			fmt.Fprintf(&builder, "%s %s %s\n", symbols.kind, name, signature)
+		}
+	}
+	return builder.String()
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// watchInterval is how often the Go files are checked for changes
+const watchInterval = 250 * time.Millisecond
+
+// watch calls update once, and then every time the Go files in dir change.
+// Files are polled, and an update waits for them to be unchanged for one
+// interval, so that a burst of saves leads to a single update.
+func watch(dir string, update func() error) error {
+	previous, err := watchFingerprint(dir)
+	if err != nil {
+		return err
+	}
+	if err := update(); err != nil {
+		slog.Error("failed to compute version", "error", err)
+	}
+
+	pending := ""
+	for {
+		time.Sleep(watchInterval)
+
+		current, err := watchFingerprint(dir)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case current != previous:
+			pending, previous = current, current
+		case pending != "":
+			// Errors, such as from a file saved half way, are fixed by the
+			// next save, so they do not stop watching
+			pending = ""
+			if err := update(); err != nil {
+				slog.Error("failed to compute version", "error", err)
+			}
+		}
+	}
+}
+
+// watchFingerprint summarizes the name, size, and modification time of every
+// Go file in dir and its subdirectories. Files that vanish while walking, such
+// as those replaced by an editor saving them, are left out, as the next poll
+// sees their replacement.
+func watchFingerprint(dir string) (string, error) {
+	var builder strings.Builder
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && path != dir {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir && skipDir(entry.Name(), analyzeOptions{includeInternal: true}) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".go" {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		fmt.Fprintf(&builder, "%s %d %d\n", path, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("watching %s: %w", dir, err)
+	}
+	return builder.String(), nil
+}
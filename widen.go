@@ -0,0 +1,253 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log/slog"
+	"strings"
+)
+
+// widenParams downgrades the changes of functions and methods whose only
+// change is parameters that became interfaces satisfied by their previous
+// types, such as from *bytes.Buffer to io.Reader, as every existing argument
+// is still accepted. The reverse, narrowing an interface to a concrete type,
+// stays a major change.
+func widenParams(current Exported, changes []Change) []Change {
+	checker := newWidenChecker(current)
+	for i, change := range changes {
+		if change.Kind != Changed || change.Bump != Major || change.Previous == "" {
+			continue
+		}
+		kind := symbolKind(current, change.Symbol)
+		if kind != "function" && kind != "method" {
+			continue
+		}
+
+		if reasons, ok := checker.widened(change.Previous, change.Current, kind == "method"); ok {
+			changes[i].Bump = Minor
+			changes[i].Reason = fmt.Sprintf("changed exported %s %s: %s", kind, change.Symbol, strings.Join(reasons, "; "))
+		}
+	}
+	return changes
+}
+
+// widenChecker tells whether a type satisfies an interface, from the method
+// sets recorded for the types of the package, and from go/types for those of
+// the standard library, which are imported by package name.
+type widenChecker struct {
+	current           Exported
+	valueMethodSets   map[string]map[string]string
+	pointerMethodSets map[string]map[string]string
+	importer          types.Importer
+	packages          map[string]*types.Package
+}
+
+func newWidenChecker(current Exported) *widenChecker {
+	return &widenChecker{
+		current:           current,
+		valueMethodSets:   methodSets(current, false),
+		pointerMethodSets: methodSets(current, true),
+		importer:          importer.ForCompiler(token.NewFileSet(), "source", nil),
+		packages:          make(map[string]*types.Package),
+	}
+}
+
+// widened reports every parameter of the previous signature that became an
+// interface its type satisfies, and false when anything else changed. The
+// receiver of a method, which is its first parameter, is left out.
+func (w *widenChecker) widened(previous, current string, method bool) ([]string, bool) {
+	previousType, previousOK := parseFuncType(previous)
+	currentType, currentOK := parseFuncType(current)
+	if !previousOK || !currentOK {
+		return nil, false
+	}
+	if method {
+		previousReceiver, previousOK := splitReceiver(previousType)
+		currentReceiver, currentOK := splitReceiver(currentType)
+		if !previousOK || !currentOK || types.ExprString(previousReceiver) != types.ExprString(currentReceiver) {
+			return nil, false
+		}
+	}
+	previousType, currentType = stripParamNames(previousType), stripParamNames(currentType)
+
+	if types.ExprString(&ast.FuncType{Params: &ast.FieldList{}, Results: previousType.Results}) != types.ExprString(&ast.FuncType{Params: &ast.FieldList{}, Results: currentType.Results}) {
+		return nil, false
+	}
+	if formatTypeParams(typeParamList(previousType.TypeParams)) != formatTypeParams(typeParamList(currentType.TypeParams)) {
+		return nil, false
+	}
+
+	previousParams, currentParams := fieldTypes(previousType.Params), fieldTypes(currentType.Params)
+	if len(previousParams) != len(currentParams) {
+		return nil, false
+	}
+
+	var reasons []string
+	for i := range previousParams {
+		previousString, currentString := types.ExprString(previousParams[i]), types.ExprString(currentParams[i])
+		if previousString == currentString {
+			continue
+		}
+		if !w.satisfies(previousParams[i], currentParams[i]) {
+			return nil, false
+		}
+		reasons = append(reasons, fmt.Sprintf("parameter %d widened from %s to interface %s", i+1, previousString, currentString))
+	}
+	return reasons, len(reasons) > 0
+}
+
+// satisfies reports whether the concrete type has every method of the
+// interface, and false when either cannot be resolved.
+func (w *widenChecker) satisfies(concrete, iface ast.Expr) bool {
+	methods, ok := w.interfaceMethods(iface)
+	if !ok {
+		return false
+	}
+	// Every type satisfies the empty interface
+	if len(methods) == 0 {
+		return true
+	}
+	methodSet, ok := w.methodSet(concrete)
+	if !ok {
+		return false
+	}
+	return satisfies(methodSet, methods)
+}
+
+func (w *widenChecker) interfaceMethods(expr ast.Expr) (map[string]string, bool) {
+	switch e := expr.(type) {
+	case *ast.InterfaceType:
+		return interfaceMethods(e)
+	case *ast.Ident:
+		if e.Name == "any" {
+			return map[string]string{}, true
+		}
+		signature, ok := w.current.Types[e.Name]
+		if !ok {
+			return nil, false
+		}
+		parsed, err := parser.ParseExpr(signature)
+		if err != nil {
+			return nil, false
+		}
+		interfaceType, ok := parsed.(*ast.InterfaceType)
+		if !ok {
+			return nil, false
+		}
+		return interfaceMethods(interfaceType)
+	case *ast.SelectorExpr:
+		named, ok := w.lookup(e)
+		if !ok {
+			return nil, false
+		}
+		interfaceType, ok := named.Underlying().(*types.Interface)
+		if !ok {
+			return nil, false
+		}
+		methods := make(map[string]string)
+		for i := range interfaceType.NumMethods() {
+			method := interfaceType.Method(i)
+			methods[method.Name()] = signatureString(method.Type().(*types.Signature))
+		}
+		return methods, true
+	}
+	return nil, false
+}
+
+func (w *widenChecker) methodSet(expr ast.Expr) (map[string]string, bool) {
+	pointer := false
+	if star, ok := expr.(*ast.StarExpr); ok {
+		pointer, expr = true, star.X
+	}
+
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if !hasKey(w.current.Types, e.Name) {
+			return nil, false
+		}
+		if pointer {
+			return w.pointerMethodSets[e.Name], true
+		}
+		return w.valueMethodSets[e.Name], true
+	case *ast.SelectorExpr:
+		named, ok := w.lookup(e)
+		if !ok {
+			return nil, false
+		}
+		var typ types.Type = named
+		if pointer {
+			typ = types.NewPointer(named)
+		}
+		set := types.NewMethodSet(typ)
+		methods := make(map[string]string, set.Len())
+		for i := range set.Len() {
+			method := set.At(i).Obj()
+			methods[method.Name()] = signatureString(method.Type().(*types.Signature))
+		}
+		return methods, true
+	}
+	return nil, false
+}
+
+// lookup imports the package named by the selector, assuming its import path
+// is its name, as for io or bytes, and returns the type it names.
+func (w *widenChecker) lookup(selector *ast.SelectorExpr) (types.Type, bool) {
+	ident, ok := selector.X.(*ast.Ident)
+	if !ok {
+		return nil, false
+	}
+
+	pkg, imported := w.packages[ident.Name]
+	if !imported {
+		var err error
+		pkg, err = w.importer.Import(ident.Name)
+		if err != nil {
+			slog.Warn("failed to import package to check a widened parameter", "package", ident.Name, "error", err)
+			pkg = nil
+		}
+		w.packages[ident.Name] = pkg
+	}
+	if pkg == nil {
+		return nil, false
+	}
+
+	object, ok := pkg.Scope().Lookup(selector.Sel.Name).(*types.TypeName)
+	if !ok {
+		return nil, false
+	}
+	return object.Type(), true
+}
+
+// signatureString formats a signature like the recorded ones, without
+// parameter names and with types qualified by package name.
+func signatureString(signature *types.Signature) string {
+	qualifier := func(pkg *types.Package) string { return pkg.Name() }
+
+	params := make([]string, 0, signature.Params().Len())
+	for i := range signature.Params().Len() {
+		typ := signature.Params().At(i).Type()
+		if signature.Variadic() && i == signature.Params().Len()-1 {
+			params = append(params, "..."+types.TypeString(typ.(*types.Slice).Elem(), qualifier))
+			continue
+		}
+		params = append(params, types.TypeString(typ, qualifier))
+	}
+
+	results := make([]string, 0, signature.Results().Len())
+	for i := range signature.Results().Len() {
+		results = append(results, types.TypeString(signature.Results().At(i).Type(), qualifier))
+	}
+
+	formatted := "func(" + strings.Join(params, ", ") + ")"
+	switch len(results) {
+	case 0:
+		return formatted
+	case 1:
+		return formatted + " " + results[0]
+	}
+	return formatted + " (" + strings.Join(results, ", ") + ")"
+}